@@ -31,7 +31,17 @@ CREATE TABLE public.sessions (
     session_id character varying(255) NOT NULL,
     player1_id character varying(255) NOT NULL,
     player2_id character varying(255) NOT NULL,
-    moves jsonb DEFAULT '[]'::jsonb NOT NULL
+    moves jsonb DEFAULT '[]'::jsonb NOT NULL,
+    status character varying(255) DEFAULT 'ACTIVE'::character varying NOT NULL,
+    source character varying(255) DEFAULT 'online'::character varying NOT NULL,
+    start_fen character varying(255) DEFAULT ''::character varying NOT NULL,
+    variant character varying(255) DEFAULT 'standard'::character varying NOT NULL,
+    clocks jsonb DEFAULT '[]'::jsonb NOT NULL,
+    chat jsonb DEFAULT '[]'::jsonb NOT NULL,
+    white_name character varying(255) DEFAULT ''::character varying NOT NULL,
+    black_name character varying(255) DEFAULT ''::character varying NOT NULL,
+    imported_by character varying(255) DEFAULT ''::character varying NOT NULL,
+    tags jsonb DEFAULT '{}'::jsonb NOT NULL
 );
 
 
@@ -51,13 +61,28 @@ CREATE TABLE public.users (
 
 ALTER TABLE public.users OWNER TO server;
 
+--
+-- Name: analyses; Type: TABLE; Schema: public; Owner: server
+--
+
+CREATE TABLE public.analyses (
+    session_id character varying(255) NOT NULL,
+    status character varying(255) DEFAULT 'pending'::character varying NOT NULL,
+    moves jsonb DEFAULT '[]'::jsonb NOT NULL,
+    white_accuracy double precision DEFAULT 0 NOT NULL,
+    black_accuracy double precision DEFAULT 0 NOT NULL
+);
+
+
+ALTER TABLE public.analyses OWNER TO server;
+
 --
 -- TOC entry 3035 (class 0 OID 24637)
 -- Dependencies: 203
 -- Data for Name: sessions; Type: TABLE DATA; Schema: public; Owner: server
 --
 
-COPY public.sessions (session_id, player1_id, player2_id, moves) FROM stdin;
+COPY public.sessions (session_id, player1_id, player2_id, moves, status, source, start_fen, variant, clocks, chat, white_name, black_name, imported_by, tags) FROM stdin;
 \.
 
 
@@ -68,6 +93,21 @@ COPY public.sessions (session_id, player1_id, player2_id, moves) FROM stdin;
 --
 
 COPY public.users (player_id, username, password) FROM stdin;
+bot-level-1	bot-level-1	!
+bot-level-2	bot-level-2	!
+bot-level-3	bot-level-3	!
+bot-level-4	bot-level-4	!
+bot-level-5	bot-level-5	!
+bot-level-6	bot-level-6	!
+imported	imported	!
+\.
+
+
+--
+-- Data for Name: analyses; Type: TABLE DATA; Schema: public; Owner: server
+--
+
+COPY public.analyses (session_id, status, moves, white_accuracy, black_accuracy) FROM stdin;
 \.
 
 
@@ -98,6 +138,14 @@ ALTER TABLE ONLY public.users
     ADD CONSTRAINT users_pkey PRIMARY KEY (player_id);
 
 
+--
+-- Name: analyses analysis_pkey; Type: CONSTRAINT; Schema: public; Owner: server
+--
+
+ALTER TABLE ONLY public.analyses
+    ADD CONSTRAINT analysis_pkey PRIMARY KEY (session_id);
+
+
 --
 -- TOC entry 2902 (class 1259 OID 24656)
 -- Name: idx_player1_id; Type: INDEX; Schema: public; Owner: server
@@ -114,6 +162,13 @@ CREATE INDEX idx_player1_id ON public.sessions USING btree (player1_id);
 CREATE INDEX idx_player2_id ON public.sessions USING btree (player2_id);
 
 
+--
+-- Name: idx_imported_by; Type: INDEX; Schema: public; Owner: server
+--
+
+CREATE INDEX idx_imported_by ON public.sessions USING btree (imported_by);
+
+
 --
 -- TOC entry 2906 (class 2606 OID 24646)
 -- Name: sessions session_player1_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: server
@@ -132,6 +187,14 @@ ALTER TABLE ONLY public.sessions
     ADD CONSTRAINT session_player2_id_fkey FOREIGN KEY (player2_id) REFERENCES public.users(player_id);
 
 
+--
+-- Name: analyses analysis_session_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: server
+--
+
+ALTER TABLE ONLY public.analyses
+    ADD CONSTRAINT analysis_session_id_fkey FOREIGN KEY (session_id) REFERENCES public.sessions(session_id);
+
+
 -- Completed on 2024-06-28 09:53:56 UTC
 
 --
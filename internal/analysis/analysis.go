@@ -0,0 +1,198 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/yelaco/go-chess-server/internal/database"
+	"github.com/yelaco/go-chess-server/internal/engine"
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+/*
+ * Evaluator
+ * Scores the positions of a game for its analysis, e.g. the built-in engine
+ * or an external UCI engine. It must leave the game as it found it
+ */
+type Evaluator interface {
+	Search(g *game.Game) (engine.SearchResult, error)
+}
+
+// move classifications, from the best move to a blunder
+const (
+	Best       = "best"
+	Good       = "good"
+	Inaccuracy = "inaccuracy"
+	Mistake    = "mistake"
+	Blunder    = "blunder"
+)
+
+// centipawns lost by a move up to which it gets each classification
+var classifications = []struct {
+	maxLoss int
+	name    string
+}{
+	{10, Best},
+	{50, Good},
+	{100, Inaccuracy},
+	{300, Mistake},
+}
+
+const (
+	// level of the built-in engine evaluating positions
+	evaluatorLevel = 3
+	// evaluations are capped, so a won position doesn't make every later move look like a blunder
+	maxScore = 1000
+)
+
+/*
+Return the built-in engine as an evaluator
+*/
+func NewBuiltinEvaluator() (Evaluator, error) {
+	return engine.New(evaluatorLevel)
+}
+
+// evaluation of a position, from the point of view of the side to move
+type evaluation struct {
+	score       int
+	mate        int
+	bestMove    string
+	whiteToMove bool
+}
+
+/*
+Evaluate every position of a match record and classify each move by the centipawns
+the mover lost compared to the best move, along with the accuracy of each player
+*/
+func Analyse(record database.Session, evaluator Evaluator) (database.Analysis, error) {
+	// players are told apart by side, imported games may not have distinct ids
+	whiteID, blackID := "white", "black"
+	g, err := game.RestoreGame([2]string{whiteID, blackID}, record.Variant, record.StartFEN, nil, "")
+	if err != nil {
+		return database.Analysis{}, err
+	}
+
+	evals := make([]evaluation, 0, len(record.Moves)+1)
+	eval, err := evaluate(g, evaluator)
+	if err != nil {
+		return database.Analysis{}, err
+	}
+	evals = append(evals, eval)
+	for i, m := range record.Moves {
+		pos, err := game.ParseMove(m)
+		if err != nil {
+			return database.Analysis{}, fmt.Errorf("invalid move %d: %s", i+1, m)
+		}
+		playerID := blackID
+		if g.GetCurrentTurn() {
+			playerID = whiteID
+		}
+		if err := g.MakeMove(playerID, pos[0], pos[1]); err != nil {
+			return database.Analysis{}, fmt.Errorf("invalid move %d: %w", i+1, err)
+		}
+		eval, err := evaluate(g, evaluator)
+		if err != nil {
+			return database.Analysis{}, fmt.Errorf("evaluation after move %d: %w", i+1, err)
+		}
+		evals = append(evals, eval)
+	}
+
+	analysis := database.Analysis{
+		SessionID: record.SessionID,
+		Status:    database.AnalysisDone,
+		Moves:     make([]database.MoveAnalysis, 0, len(record.Moves)),
+	}
+	var accuracies [2][]float64
+	for i, m := range record.Moves {
+		before, after := evals[i], evals[i+1]
+		// the mover's point of view is the opposite of the one of the side to move after the move
+		loss := max(before.score+after.score, 0)
+		classification := Blunder
+		for _, c := range classifications {
+			if loss <= c.maxLoss {
+				classification = c.name
+				break
+			}
+		}
+		if m == before.bestMove {
+			classification = Best
+		}
+
+		score, mate := after.score, after.mate
+		if !after.whiteToMove {
+			score, mate = -score, -mate
+		}
+		analysis.Moves = append(analysis.Moves, database.MoveAnalysis{
+			Move:           m,
+			Score:          score,
+			Mate:           mate,
+			BestMove:       before.bestMove,
+			Loss:           loss,
+			Classification: classification,
+		})
+
+		side := 0
+		if !before.whiteToMove {
+			side = 1
+		}
+		accuracies[side] = append(accuracies[side], moveAccuracy(before.score, -after.score))
+	}
+	analysis.WhiteAccuracy = average(accuracies[0])
+	analysis.BlackAccuracy = average(accuracies[1])
+
+	return analysis, nil
+}
+
+func evaluate(g *game.Game, evaluator Evaluator) (evaluation, error) {
+	eval := evaluation{whiteToMove: g.GetCurrentTurn()}
+	if g.IsOver() {
+		// a finished game is won, lost or drawn for the side to move, whatever the board says
+		switch g.Result() {
+		case "1-0":
+			eval.score = maxScore
+		case "0-1":
+			eval.score = -maxScore
+		}
+		if !eval.whiteToMove {
+			eval.score = -eval.score
+		}
+		return eval, nil
+	}
+
+	result, err := evaluator.Search(g)
+	if err != nil {
+		return evaluation{}, err
+	}
+	eval.score, eval.mate, eval.bestMove = result.Score, result.Mate, result.Move
+	switch {
+	case eval.mate > 0:
+		eval.score = maxScore
+	case eval.mate < 0:
+		eval.score = -maxScore
+	default:
+		eval.score = min(max(eval.score, -maxScore), maxScore)
+	}
+	return eval, nil
+}
+
+// chances of winning in percent for a side with the given score
+func winPercent(score int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(score)))-1)
+}
+
+// accuracy of a move in percent, from the winning chances of the mover before and after it
+func moveAccuracy(before, after int) float64 {
+	drop := max(winPercent(before)-winPercent(after), 0)
+	return min(max(103.1668*math.Exp(-0.04354*drop)-3.1669, 0), 100)
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yelaco/go-chess-server/internal/database"
+	"github.com/yelaco/go-chess-server/internal/engine"
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+// evaluator giving scripted scores and best moves, by number of moves played
+type scriptedEvaluator struct {
+	scores    []int
+	bestMoves []string
+}
+
+func (s scriptedEvaluator) Search(g *game.Game) (engine.SearchResult, error) {
+	ply := len(g.GetAllMoves())
+	if g.IsOver() || ply >= len(s.scores) {
+		return engine.SearchResult{}, errors.New("unexpected position")
+	}
+	return engine.SearchResult{Score: s.scores[ply], Move: s.bestMoves[ply]}, nil
+}
+
+func TestAnalyse(t *testing.T) {
+	record := database.Session{
+		SessionID: "1234",
+		Moves:     []string{"e2-e4", "e7-e5", "g1-f3", "b8-c6", "f1-c4"},
+		Variant:   "standard",
+	}
+	evaluator := scriptedEvaluator{
+		// scores of the side to move, losing 5, 30, 60, 150 and 400 centipawns
+		scores:    []int{30, -25, 55, 5, 145, 255},
+		bestMoves: []string{"e2-e4", "c7-c5", "d2-d4", "g8-f6", "f1-b5", "a7-a6"},
+	}
+	analysis, err := Analyse(record, evaluator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []database.MoveAnalysis{
+		{Move: "e2-e4", Score: 25, BestMove: "e2-e4", Loss: 5, Classification: Best},
+		{Move: "e7-e5", Score: 55, BestMove: "c7-c5", Loss: 30, Classification: Good},
+		{Move: "g1-f3", Score: -5, BestMove: "d2-d4", Loss: 60, Classification: Inaccuracy},
+		{Move: "b8-c6", Score: 145, BestMove: "g8-f6", Loss: 150, Classification: Mistake},
+		{Move: "f1-c4", Score: -255, BestMove: "f1-b5", Loss: 400, Classification: Blunder},
+	}
+	if analysis.Status != database.AnalysisDone || len(analysis.Moves) != len(want) {
+		t.Fatalf("Test analyse: got %+v", analysis)
+	}
+	for i, m := range analysis.Moves {
+		if m != want[i] {
+			t.Errorf("Test analyse: got %+v for move %d, want %+v", m, i+1, want[i])
+		}
+	}
+	for _, accuracy := range []float64{analysis.WhiteAccuracy, analysis.BlackAccuracy} {
+		if accuracy <= 0 || accuracy > 100 {
+			t.Errorf("Test analyse: got accuracy %f", accuracy)
+		}
+	}
+	if analysis.WhiteAccuracy >= analysis.BlackAccuracy {
+		t.Errorf("Test analyse: got white accuracy %f with a blunder, black %f", analysis.WhiteAccuracy, analysis.BlackAccuracy)
+	}
+}
+
+func TestAnalyseMate(t *testing.T) {
+	record := database.Session{
+		Moves:   []string{"f2-f3", "e7-e5", "g2-g4", "d8-h4"},
+		Variant: "standard",
+	}
+	// the checkmate isn't evaluated, the game is over
+	evaluator := scriptedEvaluator{
+		scores:    []int{0, 0, -50, 1000},
+		bestMoves: []string{"e2-e4", "e7-e5", "e2-e4", "d8-h4"},
+	}
+	analysis, err := Analyse(record, evaluator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mate := analysis.Moves[3]
+	if mate.Score != -maxScore || mate.Classification != Best || analysis.BlackAccuracy < 99.9 {
+		t.Errorf("Test analyse mate: got %+v, black accuracy %f", mate, analysis.BlackAccuracy)
+	}
+	if blunder := analysis.Moves[2]; blunder.Classification != Blunder {
+		t.Errorf("Test analyse mate: got %+v for the move allowing mate", blunder)
+	}
+
+	record.Moves = []string{"f2-f3", "e7-e4"}
+	if _, err := Analyse(record, evaluator); err == nil {
+		t.Error("Test analyse mate: want error for an illegal move")
+	}
+}
+
+func TestBuiltinEvaluator(t *testing.T) {
+	evaluator, err := NewBuiltinEvaluator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis, err := Analyse(database.Session{
+		Moves:   []string{"f2-f3", "e7-e5", "g2-g4", "d8-h4"},
+		Variant: "standard",
+	}, evaluator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mate := analysis.Moves[3]; mate.BestMove != "d8-h4" || mate.Classification != Best {
+		t.Errorf("Test builtin evaluator: got %+v for the mate", mate)
+	}
+	if blunder := analysis.Moves[2]; blunder.Classification != Blunder {
+		t.Errorf("Test builtin evaluator: got %+v for the move allowing mate", blunder)
+	}
+}
@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"errors"
+
+	"github.com/yelaco/go-chess-server/internal/database"
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+/*
+ * Queue
+ * Analyses stored matches in the background, one at a time in the order they were queued
+ */
+type Queue struct {
+	evaluator Evaluator
+	jobs      chan string // session ids of the matches to analyse
+}
+
+/*
+Return a queue holding up to size matches waiting for analysis, with its worker started
+*/
+func NewQueue(evaluator Evaluator, size int) *Queue {
+	q := &Queue{
+		evaluator: evaluator,
+		jobs:      make(chan string, size),
+	}
+	go q.run()
+	return q
+}
+
+/*
+Queue the analysis of a stored match, recording it as pending until it's done
+*/
+func (q *Queue) Enqueue(sessionID string) error {
+	// recorded before the worker can pick it up, so it never overwrites a finished analysis
+	if err := database.SaveAnalysis(database.Analysis{
+		SessionID: sessionID,
+		Status:    database.AnalysisPending,
+	}); err != nil {
+		return err
+	}
+	select {
+	case q.jobs <- sessionID:
+		return nil
+	default:
+		database.SaveAnalysis(database.Analysis{
+			SessionID: sessionID,
+			Status:    database.AnalysisFailed,
+		})
+		return errors.New("analysis queue full")
+	}
+}
+
+func (q *Queue) run() {
+	for sessionID := range q.jobs {
+		q.analyse(sessionID)
+	}
+}
+
+func (q *Queue) analyse(sessionID string) {
+	record, err := database.GetSessionByID(sessionID)
+	if err != nil {
+		logging.Error("couldn't load match for analysis",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	analysis, err := Analyse(record, q.evaluator)
+	if err != nil {
+		logging.Warn("analysis failed",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
+		analysis = database.Analysis{
+			SessionID: sessionID,
+			Status:    database.AnalysisFailed,
+		}
+	}
+	if err := database.SaveAnalysis(analysis); err != nil {
+		logging.Error("couldn't save analysis",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	logging.Info("match analysed",
+		zap.String("session_id", sessionID),
+		zap.String("status", analysis.Status),
+	)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/yelaco/go-chess-server/internal/database"
+	"github.com/yelaco/go-chess-server/internal/game"
+	"github.com/yelaco/go-chess-server/pkg/utils"
+)
+
+// largest PGN file accepted for import
+const maxImportSize = 1 << 20
+
+// tags of an imported game kept with its record, the players are stored apart
+var importedTags = []string{"Event", "Site", "Date", "Round"}
+
+/*
+HTTP Handler for when a user imports games played elsewhere from a PGN file sent as request body.
+Every valid game is stored as a match record of the importing user, the others are reported with their error
+*/
+func handlerGamesImport(w http.ResponseWriter, r *http.Request) {
+	type gameResult struct {
+		Index     int    `json:"index"`
+		SessionID string `json:"session_id,omitempty"`
+		White     string `json:"white"`
+		Black     string `json:"black"`
+		Result    string `json:"result,omitempty"`
+		Error     string `json:"error,omitempty"`
+	}
+	type importResponse struct {
+		Games []gameResult `json:"games"`
+	}
+
+	playerID := r.URL.Query().Get("player_id")
+	if playerID == "" {
+		respondWithError(w, http.StatusBadRequest, "Player id not included")
+		return
+	}
+	if _, err := database.GetUserByID(playerID); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid player id")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read PGN")
+		return
+	}
+
+	games, err := game.ParsePGN(string(body))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(games) == 0 {
+		respondWithError(w, http.StatusBadRequest, "No game found")
+		return
+	}
+
+	results := make([]gameResult, 0, len(games))
+	for i, pg := range games {
+		res := gameResult{
+			Index: i + 1,
+			White: tagOr(pg.Tags["White"], "?"),
+			Black: tagOr(pg.Tags["Black"], "?"),
+		}
+
+		g, err := pg.Replay([2]string{res.White, res.Black})
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+
+		sessionID := utils.GenerateUUID()
+		record := database.Session{
+			SessionID:  sessionID,
+			Player1ID:  database.ImportedPlayerID,
+			Player2ID:  database.ImportedPlayerID,
+			WhiteName:  res.White,
+			BlackName:  res.Black,
+			Moves:      g.GetAllMoves(),
+			Status:     g.GetStatus(),
+			Source:     database.SourceImported,
+			Variant:    g.Variant().Name(),
+			ImportedBy: playerID,
+			Tags:       map[string]string{},
+		}
+		for _, name := range importedTags {
+			if value := pg.Tags[name]; value != "" {
+				record.Tags[name] = value
+			}
+		}
+		if g.StartFEN() != game.StartingFEN {
+			record.StartFEN = g.StartFEN()
+		}
+		if _, err := database.InsertSession(record); err != nil {
+			res.Error = "couldn't store game"
+			results = append(results, res)
+			continue
+		}
+		res.SessionID = sessionID
+		res.Result = g.Result()
+		results = append(results, res)
+	}
+
+	respondWithJSON(w, http.StatusOK, importResponse{Games: results})
+}
+
+// value of a PGN tag, or the given one if the tag is missing
+func tagOr(value, unknown string) string {
+	if value == "" {
+		return unknown
+	}
+	return value
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yelaco/go-chess-server/internal/database"
+	"github.com/yelaco/go-chess-server/pkg/utils"
+)
+
+func TestGamesImport(t *testing.T) {
+	database.InitDB()
+	defer database.CloseDB()
+
+	user, err := database.CreateUser(utils.GenerateUUID(), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pgn := `[Event "Paris"]
+[Site "Paris FRA"]
+[Date "1858.??.??"]
+[White "Paul Morphy"]
+[Black "Duke Karl / Count Isouard"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 d6 3. d4 Bg4 4. dxe5 Bxf3 5. Qxf3 dxe5 6. Bc4 Nf6 7. Qb3 Qe7
+8. Nc3 c6 9. Bg5 b5 10. Nxb5 cxb5 11. Bxb5+ Nbd7 12. O-O-O Rd8 13. Rxd7 Rxd7
+14. Rd1 Qe6 15. Bxd7+ Nxd7 16. Qb8+ Nxb8 17. Rd8# 1-0
+`
+	rr := httptest.NewRecorder()
+	handlerGamesImport(rr, httptest.NewRequest(http.MethodPost, "/api/games/import", strings.NewReader(pgn)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("import: got status %d without player id", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handlerGamesImport(rr, httptest.NewRequest(http.MethodPost, "/api/games/import?player_id="+user.PlayerID, strings.NewReader(pgn)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import: got status %d, body %s", rr.Code, rr.Body.String())
+	}
+
+	var response struct {
+		Games []struct {
+			SessionID string `json:"session_id"`
+			Result    string `json:"result"`
+			Error     string `json:"error"`
+		} `json:"games"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Games) != 1 || response.Games[0].Error != "" || response.Games[0].Result != "1-0" {
+		t.Fatalf("import: got %+v", response.Games)
+	}
+
+	record, err := database.GetSessionByID(response.Games[0].SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Source != database.SourceImported || record.Player1ID != database.ImportedPlayerID ||
+		record.WhiteName != "Paul Morphy" || record.BlackName != "Duke Karl / Count Isouard" || len(record.Moves) != 33 ||
+		record.ImportedBy != user.PlayerID || record.Tags["Date"] != "1858.??.??" {
+		t.Errorf("import: got record %+v", record)
+	}
+
+	// the game is listed with the records of the importing user
+	records, err := database.GetSessionsByPlayerID(user.PlayerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].SessionID != record.SessionID {
+		t.Errorf("import: got records %+v of the importing user", records)
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions/"+record.SessionID+"/pgn", nil)
+	req.SetPathValue("sessionid", record.SessionID)
+	handlerSessionGetPGN(rr, req)
+	for _, tag := range []string{`[Event "Paris"]`, `[Site "Paris FRA"]`, `[Date "1858.??.??"]`, `[White "Paul Morphy"]`} {
+		if !strings.Contains(rr.Body.String(), tag) {
+			t.Errorf("import: exported PGN without %s:\n%s", tag, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/yelaco/go-chess-server/internal/database"
+)
+
+/*
+HTTP Handler for when a user wants the analysis of a finished match.
+The analysis is pending for a while after the match ends
+*/
+func handlerSessionGetAnalysis(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("sessionid")
+
+	analysis, err := database.GetAnalysisBySessionID(sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No analysis for session id")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get analysis")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, analysis)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/yelaco/go-chess-server/pkg/session"
+)
+
+type liveSessionResponse struct {
+	session.LiveSession
+	White string `json:"white"`
+	Black string `json:"black"`
+}
+
+/*
+HTTP Handler for when a user wants the ongoing matches to spectate
+*/
+func handlerSessionGetLive(w http.ResponseWriter, r *http.Request) {
+	live := []liveSessionResponse{}
+	for _, s := range session.LiveSessions() {
+		live = append(live, liveSessionResponse{
+			LiveSession: s,
+			White:       playerName(s.WhiteID),
+			Black:       playerName(s.BlackID),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, live)
+}
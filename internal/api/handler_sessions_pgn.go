@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yelaco/go-chess-server/internal/database"
+	"github.com/yelaco/go-chess-server/internal/game"
+	"github.com/yelaco/go-chess-server/pkg/session"
+)
+
+/*
+HTTP Handler for when a user wants to export a match in Portable Game Notation.
+Ongoing matches are exported as they stand, finished ones from their record
+*/
+func handlerSessionGetPGN(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("sessionid")
+	tags := game.PGNTags{
+		Event: "Casual game",
+		Site:  "go-chess-server",
+		Date:  sessionDate(sessionID),
+		Round: "-",
+	}
+
+	if whiteID, blackID, err := session.GetPlayerIds(sessionID); err == nil {
+		tags.White, tags.Black = playerName(whiteID), playerName(blackID)
+		if pgn, err := session.GetGamePGN(sessionID, tags); err == nil {
+			respondWithPGN(w, http.StatusOK, pgn)
+			return
+		}
+	}
+
+	record, err := database.GetSessionByID(sessionID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid session id")
+		return
+	}
+
+	g, err := game.RestoreGame([2]string{record.Player1ID, record.Player2ID}, record.Variant, record.StartFEN, record.Moves, record.Status)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore game")
+		return
+	}
+	for i, remaining := range record.Clocks {
+		g.SetMoveClock(i, time.Duration(remaining)*time.Millisecond)
+	}
+	if record.Source == database.SourceImported {
+		// the ids of imported games aren't creation times, the date comes from the imported tags
+		tags = game.PGNTags{
+			Event: tagOr(record.Tags["Event"], "Imported game"),
+			Site:  tagOr(record.Tags["Site"], "?"),
+			Date:  tagOr(record.Tags["Date"], "????.??.??"),
+			Round: tagOr(record.Tags["Round"], "-"),
+			White: record.WhiteName,
+			Black: record.BlackName,
+		}
+	} else {
+		tags.White, tags.Black = playerName(record.Player1ID), playerName(record.Player2ID)
+	}
+
+	respondWithPGN(w, http.StatusOK, g.PGN(tags))
+}
+
+// session ids are the creation time of the match in unix nanoseconds
+func sessionDate(sessionID string) string {
+	nsec, err := strconv.ParseInt(sessionID, 10, 64)
+	if err != nil {
+		return "????.??.??"
+	}
+	return time.Unix(0, nsec).UTC().Format("2006.01.02")
+}
+
+func playerName(playerID string) string {
+	user, err := database.GetUserByID(playerID)
+	if err != nil {
+		return "?"
+	}
+	return user.Username
+}
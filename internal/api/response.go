@@ -32,3 +32,9 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	w.Write(dat)
 }
+
+func respondWithPGN(w http.ResponseWriter, code int, pgn string) {
+	w.Header().Set("Content-Type", "application/x-chess-pgn")
+	w.WriteHeader(code)
+	w.Write([]byte(pgn))
+}
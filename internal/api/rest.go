@@ -13,7 +13,11 @@ func StartRESTServer(port string) error {
 	http.HandleFunc("POST /api/users", handlerUsersCreate)
 	http.HandleFunc("POST /api/login", handlerLogin)
 	http.HandleFunc("GET /api/sessions", handlerSessionGet)
+	http.HandleFunc("GET /api/sessions/live", handlerSessionGetLive)
 	http.HandleFunc("GET /api/sessions/{sessionid}", handlerSessionGetFromID)
+	http.HandleFunc("GET /api/sessions/{sessionid}/pgn", handlerSessionGetPGN)
+	http.HandleFunc("GET /api/sessions/{sessionid}/analysis", handlerSessionGetAnalysis)
+	http.HandleFunc("POST /api/games/import", handlerGamesImport)
 	logging.Info("rest server started", zap.String("port", config.RESTPort))
 
 	return http.ListenAndServe(":"+port, nil)
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"encoding/json"
+)
+
+// progress of the analysis of a match
+const (
+	AnalysisPending = "pending"
+	AnalysisDone    = "done"
+	AnalysisFailed  = "failed"
+)
+
+type Analysis struct {
+	SessionID     string         `json:"session_id"`
+	Status        string         `json:"status"`
+	Moves         []MoveAnalysis `json:"moves"`
+	WhiteAccuracy float64        `json:"white_accuracy"`
+	BlackAccuracy float64        `json:"black_accuracy"`
+}
+
+// evaluation of a move of a match, scores in centipawns from white's point of view
+type MoveAnalysis struct {
+	Move           string `json:"move"`
+	Score          int    `json:"score"`          // after the move
+	Mate           int    `json:"mate,omitempty"` // moves to mate after the move, negative when black mates
+	BestMove       string `json:"best_move"`
+	Loss           int    `json:"loss"` // centipawns the mover lost compared to the best move
+	Classification string `json:"classification"`
+}
+
+func GetAnalysisBySessionID(sessionID string) (Analysis, error) {
+	var analysis Analysis
+	query := `SELECT session_id, status, moves, white_accuracy, black_accuracy FROM analyses WHERE session_id = $1`
+	row := db.QueryRow(query, sessionID)
+
+	var movesJSON string
+	err := row.Scan(&analysis.SessionID, &analysis.Status, &movesJSON, &analysis.WhiteAccuracy, &analysis.BlackAccuracy)
+	if err != nil {
+		return Analysis{}, err
+	}
+	if err := json.Unmarshal([]byte(movesJSON), &analysis.Moves); err != nil {
+		return Analysis{}, err
+	}
+
+	return analysis, nil
+}
+
+/*
+Insert the analysis of a match, or replace the one stored for the match
+*/
+func SaveAnalysis(analysis Analysis) error {
+	moves := analysis.Moves
+	if moves == nil {
+		moves = []MoveAnalysis{}
+	}
+	movesJSON, err := json.Marshal(moves)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO analyses (session_id, status, moves, white_accuracy, black_accuracy)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (session_id) DO UPDATE
+        SET status = $2, moves = $3, white_accuracy = $4, black_accuracy = $5
+    `
+	_, err = db.Exec(query, analysis.SessionID, analysis.Status, movesJSON, analysis.WhiteAccuracy, analysis.BlackAccuracy)
+	return err
+}
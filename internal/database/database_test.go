@@ -39,7 +39,15 @@ func TestSession(t *testing.T) {
 		t.Error("nil db")
 	}
 
-	newSession, err := InsertSession("1234", "fd9a179f-c035-4e50-82f5-5d1efc844316", "0046bb25-3f06-44f8-84e2-d84e2fff42e9", []string{"e2-e4"})
+	newSession, err := InsertSession(Session{
+		SessionID: "1234",
+		Player1ID: "fd9a179f-c035-4e50-82f5-5d1efc844316",
+		Player2ID: "0046bb25-3f06-44f8-84e2-d84e2fff42e9",
+		Moves:     []string{"e2-e4"},
+		Status:    "WHITE_RESIGN",
+		Source:    SourceOnline,
+		Variant:   "standard",
+	})
 	if err != nil {
 		t.Error(err)
 	}
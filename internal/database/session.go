@@ -3,22 +3,49 @@ package database
 import (
 	"encoding/json"
 	"log"
+	"time"
 )
 
+// where a match record comes from
+const (
+	SourceOnline   = "online"
+	SourceImported = "imported"
+)
+
+// player id of both sides of imported games, whose players have no account
+const ImportedPlayerID = "imported"
+
 type Session struct {
-	SessionID string   `json:"session_id"`
-	Player1ID string   `json:"player1_id"`
-	Player2ID string   `json:"player2_id"`
-	Moves     []string `json:"moves"`
+	SessionID  string            `json:"session_id"`
+	Player1ID  string            `json:"player1_id"`
+	Player2ID  string            `json:"player2_id"`
+	Moves      []string          `json:"moves"`
+	Status     string            `json:"status"`
+	Source     string            `json:"source"`
+	StartFEN   string            `json:"start_fen,omitempty"` // empty for the standard starting position
+	Variant    string            `json:"variant"`
+	Clocks     []int64           `json:"clocks,omitempty"`     // milliseconds left to the mover after each move, empty without clocks
+	Chat       []ChatMessage     `json:"-"`                    // messages as sent, before banned words are masked, kept for moderation only
+	WhiteName  string            `json:"white_name,omitempty"` // player names of imported games
+	BlackName  string            `json:"black_name,omitempty"`
+	ImportedBy string            `json:"imported_by,omitempty"` // player id of the user who imported the game
+	Tags       map[string]string `json:"tags,omitempty"`        // Event, Site, Date and Round tags of imported games
+}
+
+type ChatMessage struct {
+	PlayerID string    `json:"player_id"`
+	Channel  string    `json:"channel"` // "players" or "spectators"
+	Text     string    `json:"text"`
+	Time     time.Time `json:"time"`
 }
 
 func GetSessionByID(sessionID string) (Session, error) {
 	var session Session
-	query := `SELECT session_id, player1_id, player2_id, moves FROM sessions WHERE session_id = $1`
+	query := `SELECT session_id, player1_id, player2_id, moves, status, source, start_fen, variant, clocks, chat, white_name, black_name, imported_by, tags FROM sessions WHERE session_id = $1`
 	row := db.QueryRow(query, sessionID)
 
-	var moveJSON string
-	err := row.Scan(&session.SessionID, &session.Player1ID, &session.Player2ID, &moveJSON)
+	var moveJSON, clocksJSON, chatJSON, tagsJSON string
+	err := row.Scan(&session.SessionID, &session.Player1ID, &session.Player2ID, &moveJSON, &session.Status, &session.Source, &session.StartFEN, &session.Variant, &clocksJSON, &chatJSON, &session.WhiteName, &session.BlackName, &session.ImportedBy, &tagsJSON)
 	if err != nil {
 		return Session{}, err
 	}
@@ -27,6 +54,18 @@ func GetSessionByID(sessionID string) (Session, error) {
 	if err != nil {
 		return Session{}, err
 	}
+	err = json.Unmarshal([]byte(clocksJSON), &session.Clocks)
+	if err != nil {
+		return Session{}, err
+	}
+	err = json.Unmarshal([]byte(chatJSON), &session.Chat)
+	if err != nil {
+		return Session{}, err
+	}
+	err = json.Unmarshal([]byte(tagsJSON), &session.Tags)
+	if err != nil {
+		return Session{}, err
+	}
 
 	return session, nil
 }
@@ -34,7 +73,7 @@ func GetSessionByID(sessionID string) (Session, error) {
 func GetSessionsByPlayerID(playerID string) ([]Session, error) {
 	var sessions []Session
 
-	query := `SELECT session_id, player1_id, player2_id, moves FROM sessions WHERE player1_id = $1 OR player2_id = $1 ORDER BY session_id DESC LIMIT 5`
+	query := `SELECT session_id, player1_id, player2_id, moves, status, source, start_fen, variant, clocks, chat, white_name, black_name, imported_by, tags FROM sessions WHERE player1_id = $1 OR player2_id = $1 OR imported_by = $1 ORDER BY session_id DESC LIMIT 5`
 	rows, err := db.Query(query, playerID)
 	if err != nil {
 		return nil, err
@@ -43,14 +82,23 @@ func GetSessionsByPlayerID(playerID string) ([]Session, error) {
 
 	for rows.Next() {
 		var session Session
-		var movesJSON string
-		err := rows.Scan(&session.SessionID, &session.Player1ID, &session.Player2ID, &movesJSON)
+		var movesJSON, clocksJSON, chatJSON, tagsJSON string
+		err := rows.Scan(&session.SessionID, &session.Player1ID, &session.Player2ID, &movesJSON, &session.Status, &session.Source, &session.StartFEN, &session.Variant, &clocksJSON, &chatJSON, &session.WhiteName, &session.BlackName, &session.ImportedBy, &tagsJSON)
 		if err != nil {
 			return nil, err
 		}
 		if err := json.Unmarshal([]byte(movesJSON), &session.Moves); err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal([]byte(clocksJSON), &session.Clocks); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(chatJSON), &session.Chat); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(tagsJSON), &session.Tags); err != nil {
+			return nil, err
+		}
 		sessions = append(sessions, session)
 	}
 
@@ -61,27 +109,46 @@ func GetSessionsByPlayerID(playerID string) ([]Session, error) {
 	return sessions, nil
 }
 
-func InsertSession(sessionID, player1ID, player2ID string, moves []string) (Session, error) {
-	movesJSON, err := json.Marshal(moves)
+func InsertSession(session Session) (Session, error) {
+	movesJSON, err := json.Marshal(session.Moves)
 	if err != nil {
 		log.Fatal(err)
 	}
+	clocks := session.Clocks
+	if clocks == nil {
+		clocks = []int64{}
+	}
+	clocksJSON, err := json.Marshal(clocks)
+	if err != nil {
+		return Session{}, err
+	}
+	chat := session.Chat
+	if chat == nil {
+		chat = []ChatMessage{}
+	}
+	chatJSON, err := json.Marshal(chat)
+	if err != nil {
+		return Session{}, err
+	}
+	tags := session.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return Session{}, err
+	}
 
-	ist, err := db.Prepare("INSERT INTO sessions (session_id, player1_id, player2_id, moves) VALUES ($1, $2, $3, $4)")
+	ist, err := db.Prepare("INSERT INTO sessions (session_id, player1_id, player2_id, moves, status, source, start_fen, variant, clocks, chat, white_name, black_name, imported_by, tags) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)")
 	if err != nil {
 		return Session{}, err
 	}
 	defer ist.Close()
 
-	_, err = ist.Exec(sessionID, player1ID, player2ID, movesJSON)
+	_, err = ist.Exec(session.SessionID, session.Player1ID, session.Player2ID, movesJSON, session.Status, session.Source, session.StartFEN, session.Variant, clocksJSON, chatJSON, session.WhiteName, session.BlackName, session.ImportedBy, tagsJSON)
 	if err != nil {
 		return Session{}, err
 	}
 
-	return Session{
-		SessionID: sessionID,
-		Player1ID: player1ID,
-		Player2ID: player2ID,
-		Moves:     moves,
-	}, nil
+	return session, nil
 }
@@ -24,6 +24,17 @@ func GetUserByUsername(username string) (User, error) {
 	return user, err
 }
 
+func GetUserByID(playerID string) (User, error) {
+	user := User{}
+	query := "SELECT player_id, username, password FROM users WHERE player_id = $1"
+	if db == nil {
+		return User{}, errors.New("db nil")
+	}
+	row := db.QueryRow(query, playerID)
+	err := row.Scan(&user.PlayerID, &user.Username, &user.Password)
+	return user, err
+}
+
 func CreateUser(username, password string) (User, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	if err != nil {
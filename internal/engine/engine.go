@@ -0,0 +1,348 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+/*
+ * Engine
+ * Alpha-beta search over the moves of a game position, deepened one ply at a time
+ * until the depth or the thinking time of its level is reached. Positions
+ * are scored by material and piece placement and kept in a transposition table
+ */
+type Engine struct {
+	level    Level
+	table    map[uint64]tableEntry
+	deadline time.Time
+	line     []uint64 // hashes of the positions from the root to the one searched
+	nodes    int
+	stopped  bool // the thinking time ran out, the current iteration is dropped
+}
+
+/*
+ * Level
+ * Strength of the engine, given by how deep and how long it searches
+ */
+type Level struct {
+	Depth    int           // deepest iteration of the search, in plies
+	MoveTime time.Duration // time to think about a move
+}
+
+// strength levels from 1, the weakest, to 6
+var Levels = []Level{
+	{Depth: 1, MoveTime: 50 * time.Millisecond},
+	{Depth: 2, MoveTime: 100 * time.Millisecond},
+	{Depth: 3, MoveTime: 250 * time.Millisecond},
+	{Depth: 4, MoveTime: 500 * time.Millisecond},
+	{Depth: 5, MoveTime: time.Second},
+	{Depth: 6, MoveTime: 2 * time.Second},
+}
+
+const (
+	mateScore = 100000
+	infinity  = 1000000
+	// scores beyond it are mates, counted in plies from the root
+	mateThreshold = mateScore - 1000
+	// positions kept in the transposition table before it is cleared
+	maxTableSize = 1 << 20
+)
+
+type bound uint8
+
+const (
+	exact bound = iota
+	lowerBound
+	upperBound
+)
+
+type tableEntry struct {
+	depth int
+	score int
+	bound bound
+	move  game.SearchMove // best move found, searched first next time
+}
+
+/*
+ * SearchResult
+ * Best move found by the engine and what it knows about it
+ */
+type SearchResult struct {
+	Move  string   // in the form of "e2-e4", as listed by game.LegalMoves
+	Score int      // in centipawns, from the point of view of the side to move
+	Mate  int      // moves to mate, negative when the side to move gets mated, 0 if no mate is found
+	Depth int      // depth of the last completed iteration
+	Nodes int      // positions visited
+	PV    []string // expected line of play in UCI notation, from engines reporting it
+}
+
+/*
+Return an engine playing at the given strength level, from 1 to len(Levels)
+*/
+func New(level int) (*Engine, error) {
+	if level < 1 || level > len(Levels) {
+		return nil, fmt.Errorf("invalid engine level: %d, want 1 to %d", level, len(Levels))
+	}
+	return &Engine{
+		level: Levels[level-1],
+		table: map[uint64]tableEntry{},
+	}, nil
+}
+
+/*
+Return the best move found for the side to move, in the form of "e2-e4".
+The game is only read, the search plays its moves on a copy of the position
+*/
+func (e *Engine) BestMove(g *game.Game) (string, error) {
+	return e.BestMoveFrom(g.SearchPosition())
+}
+
+/*
+Return the best move found in the position taken from a game, in the form of "e2-e4"
+*/
+func (e *Engine) BestMoveFrom(root game.SearchPosition) (string, error) {
+	result, err := e.SearchFrom(root)
+	return result.Move, err
+}
+
+/*
+Search the position of the game with iterative deepening and return the best move
+of the deepest iteration completed within the thinking time
+*/
+func (e *Engine) Search(g *game.Game) (SearchResult, error) {
+	return e.SearchFrom(g.SearchPosition())
+}
+
+/*
+Search the position taken from a game as Search does
+*/
+func (e *Engine) SearchFrom(root game.SearchPosition) (SearchResult, error) {
+	if root.IsOver() {
+		return SearchResult{}, errors.New("game is over")
+	}
+	moves := root.Moves()
+	if len(moves) == 0 {
+		return SearchResult{}, errors.New("no legal move")
+	}
+
+	e.deadline = time.Now().Add(e.level.MoveTime)
+	e.nodes, e.stopped = 0, false
+	if len(e.table) > maxTableSize {
+		e.table = map[uint64]tableEntry{}
+	}
+
+	result := SearchResult{Move: moves[0].String()}
+	for depth := 1; depth <= e.level.Depth; depth++ {
+		score, move := e.searchRoot(&root, moves, depth)
+		if e.stopped {
+			break
+		}
+		result = SearchResult{Move: move.String(), Score: score, Mate: mateIn(score), Depth: depth}
+		// a forced mate can't be improved on by searching deeper
+		if score > mateThreshold || score < -mateThreshold {
+			break
+		}
+	}
+	result.Nodes = e.nodes
+	return result, nil
+}
+
+func (e *Engine) searchRoot(root *game.SearchPosition, moves []game.SearchMove, depth int) (int, game.SearchMove) {
+	entry := e.table[root.Hash()]
+	alpha, bestMove, found := -infinity, game.SearchMove{}, false
+	for _, m := range orderMoves(root, moves, entry.move) {
+		score := e.searchMove(root, m, depth-1, 1, -infinity, -alpha)
+		if e.stopped {
+			return 0, game.SearchMove{}
+		}
+		if score > alpha || !found {
+			alpha, bestMove, found = score, m, true
+		}
+	}
+	e.table[root.Hash()] = tableEntry{depth: depth, score: alpha, bound: exact, move: bestMove}
+	return alpha, bestMove
+}
+
+// score of the move for the side playing it
+func (e *Engine) searchMove(p *game.SearchPosition, m game.SearchMove, depth, ply, alpha, beta int) int {
+	next := p.Play(m)
+	e.line = append(e.line, p.Hash())
+	score := e.negamax(&next, depth, ply, -beta, -alpha)
+	e.line = e.line[:len(e.line)-1]
+	return -score
+}
+
+func (e *Engine) negamax(p *game.SearchPosition, depth, ply, alpha, beta int) int {
+	if e.visit() {
+		return 0
+	}
+	if p.IsOver() {
+		return terminalScore(p, ply)
+	}
+	// a position met again in the line or earlier in the game is taken as a draw
+	if p.RepetitionCount() > 0 || slices.Contains(e.line, p.Hash()) {
+		return 0
+	}
+	if depth == 0 {
+		return e.quiesce(p, ply, alpha, beta)
+	}
+
+	hash := p.Hash()
+	entry, found := e.table[hash]
+	if found && entry.depth >= depth {
+		score := fromTable(entry.score, ply)
+		switch {
+		case entry.bound == exact,
+			entry.bound == lowerBound && score >= beta,
+			entry.bound == upperBound && score <= alpha:
+			return score
+		}
+	}
+
+	origAlpha := alpha
+	best, bestMove := -infinity, game.SearchMove{}
+	for _, m := range orderMoves(p, p.Moves(), entry.move) {
+		score := e.searchMove(p, m, depth-1, ply+1, alpha, beta)
+		if e.stopped {
+			return 0
+		}
+		if score > best {
+			best, bestMove = score, m
+		}
+		alpha = max(alpha, score)
+		if alpha >= beta {
+			break
+		}
+	}
+
+	b := exact
+	if best <= origAlpha {
+		b = upperBound
+	} else if best >= beta {
+		b = lowerBound
+	}
+	e.table[hash] = tableEntry{depth: depth, score: toTable(best, ply), bound: b, move: bestMove}
+	return best
+}
+
+// search captures only until the position is quiet, so exchanges are not cut in the middle
+func (e *Engine) quiesce(p *game.SearchPosition, ply, alpha, beta int) int {
+	if e.visit() {
+		return 0
+	}
+	if p.IsOver() {
+		return terminalScore(p, ply)
+	}
+
+	standPat := evaluate(newBoard(p))
+	if standPat >= beta {
+		return standPat
+	}
+	alpha = max(alpha, standPat)
+
+	captures := []game.SearchMove{}
+	for _, m := range p.Moves() {
+		if p.Captured(m) != 0 {
+			captures = append(captures, m)
+		}
+	}
+	for _, m := range orderMoves(p, captures, game.SearchMove{}) {
+		score := e.searchMove(p, m, 0, ply+1, alpha, beta)
+		if e.stopped {
+			return 0
+		}
+		if score >= beta {
+			return score
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha
+}
+
+// count the node and report whether the thinking time has run out
+func (e *Engine) visit() bool {
+	e.nodes++
+	if e.nodes%1024 == 0 && time.Now().After(e.deadline) {
+		e.stopped = true
+	}
+	return e.stopped
+}
+
+// score of a finished game for the side to move, quicker mates scoring higher
+func terminalScore(p *game.SearchPosition, ply int) int {
+	result := p.Result()
+	if result != "1-0" && result != "0-1" {
+		return 0
+	}
+	if (result == "1-0") == p.WhiteToMove() {
+		return mateScore - ply
+	}
+	return -(mateScore - ply)
+}
+
+// moves to the mate a score stands for, 0 if it isn't a mate score
+func mateIn(score int) int {
+	switch {
+	case score > mateThreshold:
+		return (mateScore - score + 1) / 2
+	case score < -mateThreshold:
+		return -(mateScore + score) / 2
+	}
+	return 0
+}
+
+// score of a mate in the given number of moves, as scored by the search
+func mateScoreIn(moves int) int {
+	if moves > 0 {
+		return mateScore - (2*moves - 1)
+	}
+	return -(mateScore + 2*moves)
+}
+
+// mate scores are stored relative to the position, not to the root
+func toTable(score, ply int) int {
+	switch {
+	case score > mateThreshold:
+		return score + ply
+	case score < -mateThreshold:
+		return score - ply
+	}
+	return score
+}
+
+func fromTable(score, ply int) int {
+	switch {
+	case score > mateThreshold:
+		return score - ply
+	case score < -mateThreshold:
+		return score + ply
+	}
+	return score
+}
+
+// moves in the order they are searched: the best move known first,
+// then captures of the most valuable pieces by the least valuable ones
+func orderMoves(p *game.SearchPosition, moves []game.SearchMove, best game.SearchMove) []game.SearchMove {
+	ordered := make([]game.SearchMove, len(moves))
+	copy(ordered, moves)
+	keys := make(map[game.SearchMove]int, len(moves))
+	for _, m := range moves {
+		switch victim := p.Captured(m); {
+		case m == best:
+			keys[m] = infinity
+		case victim != 0:
+			keys[m] = 10*pieceValues[lower(victim)] - pieceValues[lower(p.Moved(m))]
+		case m.Promotion() != 0:
+			keys[m] = pieceValues[m.Promotion()]
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return keys[ordered[i]] > keys[ordered[j]]
+	})
+	return ordered
+}
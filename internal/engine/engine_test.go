@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+func TestBestMove(t *testing.T) {
+	testcases := []struct {
+		name string
+		fen  string
+		want string
+	}{
+		{"mate in one", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1-a8"},
+		{"hanging queen", "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", "d1-d5"},
+		{"black mates", "r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1", "a8-a1"},
+	}
+	for _, tc := range testcases {
+		for level := 1; level <= 3; level++ {
+			igame, err := game.InitGameFromFEN([2]string{"white", "black"}, tc.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err := New(level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			move, err := e.BestMove(igame)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if move != tc.want {
+				t.Errorf("Test %s at level %d: got %s, want %s", tc.name, level, move, tc.want)
+			}
+			if igame.FEN() != tc.fen {
+				t.Errorf("Test %s at level %d: position changed to %s", tc.name, level, igame.FEN())
+			}
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	e, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	igame := game.InitGame([2]string{"white", "black"})
+	result, err := e.Search(igame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Depth < 1 || result.Nodes == 0 {
+		t.Errorf("Test search: got depth %d, %d nodes", result.Depth, result.Nodes)
+	}
+	if err := play(igame, result.Move); err != nil {
+		t.Errorf("Test search: illegal move %s: %v", result.Move, err)
+	}
+}
+
+func TestQuiesceEnpassant(t *testing.T) {
+	igame, err := game.InitGameFromFEN([2]string{"white", "black"}, "4k3/8/8/8/1p6/8/P7/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := play(igame, "a2-a4"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := New(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.deadline = time.Now().Add(time.Minute)
+
+	// taking the pawn en passant is the only capture
+	p := igame.SearchPosition()
+	if score, standPat := e.quiesce(&p, 0, -infinity, infinity), evaluate(newBoard(&p)); score < standPat+pieceValues['p'] {
+		t.Errorf("Test quiesce en passant: got %d, stand pat %d", score, standPat)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, level := range []int{0, len(Levels) + 1} {
+		if _, err := New(level); err == nil {
+			t.Errorf("Test new: want error for level %d", level)
+		}
+	}
+}
+
+// play the move for the side to move
+func play(g *game.Game, m string) error {
+	pos, err := game.ParseMove(m)
+	if err != nil {
+		return err
+	}
+	whiteID, blackID := g.GetPlayerIds()
+	if !g.GetCurrentTurn() {
+		whiteID = blackID
+	}
+	return g.MakeMove(whiteID, pos[0], pos[1])
+}
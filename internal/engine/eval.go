@@ -0,0 +1,130 @@
+package engine
+
+import "github.com/yelaco/go-chess-server/internal/game"
+
+// material value of each piece in centipawns, by FEN letter
+var pieceValues = [...]int{
+	'p': 100,
+	'n': 320,
+	'b': 330,
+	'r': 500,
+	'q': 900,
+	'k': 0,
+}
+
+// piece-square bonuses from white's point of view, the 8th rank first
+var pieceSquares = [...][64]int{
+	'p': {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	'n': {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	'b': {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	'r': {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+	'q': {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	'k': {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+}
+
+// pieces of a position by square, a1 first, as FEN letters with 0 for empty squares
+type board struct {
+	squares     [64]byte
+	pockets     string // pieces in hand, in Crazyhouse
+	whiteToMove bool
+}
+
+func newBoard(p *game.SearchPosition) board {
+	return board{squares: p.Board(), pockets: p.Pockets(), whiteToMove: p.WhiteToMove()}
+}
+
+func isWhite(piece byte) bool {
+	return piece >= 'A' && piece <= 'Z'
+}
+
+func lower(piece byte) byte {
+	return piece | 0x20
+}
+
+// material and piece placement in centipawns, from the point of view of the side to move
+func evaluate(b board) int {
+	score := 0
+	for sq, piece := range b.squares {
+		if piece == 0 {
+			continue
+		}
+		kind := lower(piece)
+		// the tables start from the 8th rank, mirrored for black
+		index := (7-sq/8)*8 + sq%8
+		if !isWhite(piece) {
+			index = sq
+		}
+		value := pieceValues[kind] + pieceSquares[kind][index]
+		if isWhite(piece) {
+			score += value
+		} else {
+			score -= value
+		}
+	}
+	for i := 0; i < len(b.pockets); i++ {
+		if piece := b.pockets[i]; isWhite(piece) {
+			score += pieceValues[lower(piece)]
+		} else {
+			score -= pieceValues[piece]
+		}
+	}
+
+	if !b.whiteToMove {
+		return -score
+	}
+	return score
+}
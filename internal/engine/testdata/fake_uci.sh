@@ -0,0 +1,44 @@
+#!/bin/sh
+# Fake UCI engine for the adapter tests. It always plays $FAKE_UCI_BESTMOVE (e2e4 by default)
+# and logs the commands it receives to $FAKE_UCI_LOG. It exits on "go" if the file
+# $FAKE_UCI_CRASH exists, removing it first so it only crashes once, and never answers
+# "go" nor "stop" if $FAKE_UCI_HANG is set.
+
+log() {
+	if [ -n "$FAKE_UCI_LOG" ]; then
+		echo "$1" >> "$FAKE_UCI_LOG"
+	fi
+}
+
+while read -r line; do
+	log "$line"
+	case "$line" in
+	uci)
+		echo "id name Fake Engine"
+		echo "id author go-chess-server"
+		echo "option name UCI_Chess960 type check default false"
+		echo "option name Skill Level type spin default 20 min 0 max 20"
+		echo "uciok"
+		;;
+	isready)
+		echo "readyok"
+		;;
+	go*)
+		if [ -n "$FAKE_UCI_CRASH" ] && [ -f "$FAKE_UCI_CRASH" ]; then
+			rm -f "$FAKE_UCI_CRASH"
+			exit 1
+		fi
+		if [ -n "$FAKE_UCI_HANG" ]; then
+			continue
+		fi
+		echo "info string thinking"
+		echo "info depth 1 seldepth 1 multipv 1 score cp 20 nodes 20 pv e2e4"
+		echo "info depth 2 seldepth 3 multipv 1 score ${FAKE_UCI_SCORE:-cp 35} nodes 400 nps 40000 pv ${FAKE_UCI_BESTMOVE:-e2e4} e7e5"
+		echo "info depth 2 multipv 2 score cp -10 pv d2d4"
+		echo "bestmove ${FAKE_UCI_BESTMOVE:-e2e4} ponder e7e5"
+		;;
+	quit)
+		exit 0
+		;;
+	esac
+done
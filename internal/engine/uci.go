@@ -0,0 +1,432 @@
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+var (
+	// time given to the engine to answer "uci" and "isready"
+	handshakeTimeout = 5 * time.Second
+	// time given to the engine past the move time before it's told to stop, then restarted
+	stopGrace = time.Second
+
+	errEngineExited = errors.New("uci engine exited")
+)
+
+// option names of the variants in UCI engines supporting them, e.g. Fairy-Stockfish
+var uciVariants = map[string]string{
+	"kingofthehill": "kingofthehill",
+	"threecheck":    "3check",
+	"antichess":     "antichess",
+	"crazyhouse":    "crazyhouse",
+}
+
+/*
+ * UCIConfig
+ * How to run an external engine speaking the Universal Chess Interface
+ */
+type UCIConfig struct {
+	Path     string
+	Args     []string
+	MoveTime time.Duration     // time to think about a move
+	Options  map[string]string // set with "setoption" after each start, e.g. "Skill Level"
+}
+
+/*
+ * UCIEngine
+ * Adapter running an external UCI engine, e.g. Stockfish, as a subprocess.
+ * It's restarted when it crashes or doesn't answer in time. Searches are
+ * run one at a time
+ */
+type UCIEngine struct {
+	config  UCIConfig
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string // output of the engine, closed once it exits
+	name    string
+	options map[string]bool // options the engine supports
+	variant string          // variant the engine is set up for
+}
+
+/*
+Start the engine and go through the UCI handshake
+*/
+func NewUCIEngine(config UCIConfig) (*UCIEngine, error) {
+	if config.MoveTime <= 0 {
+		return nil, errors.New("invalid move time")
+	}
+	e := &UCIEngine{config: config}
+	if err := e.start(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+/*
+Return the name the engine gave in the handshake
+*/
+func (e *UCIEngine) Name() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.name
+}
+
+/*
+Return the best move found by the engine for the side to move, in the form of "e2-e4"
+*/
+func (e *UCIEngine) BestMove(g *game.Game) (string, error) {
+	result, err := e.Search(g)
+	return result.Move, err
+}
+
+/*
+Search the position of the game for the move time and return the best move with the
+last score, depth and principal variation reported. An engine that crashed is
+restarted and searches again
+*/
+func (e *UCIEngine) Search(g *game.Game) (SearchResult, error) {
+	if g.IsOver() {
+		return SearchResult{}, errors.New("game is over")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result, err := e.search(g)
+	if errors.Is(err, errEngineExited) {
+		logging.Warn("uci engine exited, restarting",
+			zap.String("engine", e.name),
+		)
+		if err := e.restart(); err != nil {
+			return SearchResult{}, err
+		}
+		result, err = e.search(g)
+	}
+	return result, err
+}
+
+/*
+Stop the engine
+*/
+func (e *UCIEngine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stop()
+}
+
+// start the engine process and go through the handshake
+func (e *UCIEngine) start() error {
+	cmd := exec.Command(e.config.Path, e.config.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("couldn't start uci engine: %w", err)
+	}
+
+	lines := make(chan string, 64)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(lines)
+		cmd.Wait()
+	}()
+	e.cmd, e.stdin, e.lines = cmd, stdin, lines
+	e.name, e.options, e.variant = e.config.Path, map[string]bool{}, ""
+
+	if err := e.handshake(); err != nil {
+		e.stop()
+		return err
+	}
+	return nil
+}
+
+func (e *UCIEngine) handshake() error {
+	if err := e.send("uci"); err != nil {
+		return err
+	}
+	deadline := time.After(handshakeTimeout)
+	for {
+		line, err := e.readLine(deadline)
+		if err != nil {
+			return fmt.Errorf("uci handshake: %w", err)
+		}
+		fields := strings.Fields(line)
+		switch {
+		case line == "uciok":
+			for name, value := range e.config.Options {
+				if err := e.setOption(name, value); err != nil {
+					return err
+				}
+			}
+			return e.ready()
+		case len(fields) > 2 && fields[0] == "id" && fields[1] == "name":
+			e.name = strings.Join(fields[2:], " ")
+		case len(fields) > 2 && fields[0] == "option" && fields[1] == "name":
+			// the name runs until the "type" token and may hold spaces
+			name := fields[2:]
+			for i, field := range name {
+				if field == "type" {
+					name = name[:i]
+					break
+				}
+			}
+			e.options[strings.Join(name, " ")] = true
+		}
+	}
+}
+
+// wait until the engine is done with the commands sent so far
+func (e *UCIEngine) ready() error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	deadline := time.After(handshakeTimeout)
+	for {
+		line, err := e.readLine(deadline)
+		if err != nil {
+			return fmt.Errorf("uci isready: %w", err)
+		}
+		if line == "readyok" {
+			return nil
+		}
+	}
+}
+
+func (e *UCIEngine) restart() error {
+	e.stop()
+	return e.start()
+}
+
+func (e *UCIEngine) stop() error {
+	if e.cmd == nil {
+		return nil
+	}
+	e.send("quit")
+	e.stdin.Close()
+	select {
+	case <-e.exited():
+	case <-time.After(stopGrace):
+		e.cmd.Process.Kill()
+	}
+	e.cmd = nil
+	return nil
+}
+
+// closed once the output of the engine is drained after it exited
+func (e *UCIEngine) exited() <-chan struct{} {
+	done := make(chan struct{})
+	go func(lines chan string) {
+		for range lines {
+		}
+		close(done)
+	}(e.lines)
+	return done
+}
+
+func (e *UCIEngine) search(g *game.Game) (SearchResult, error) {
+	if e.cmd == nil {
+		return SearchResult{}, errEngineExited
+	}
+	if err := e.setVariant(g.Variant().Name()); err != nil {
+		return SearchResult{}, err
+	}
+
+	position := "position fen " + g.StartFEN()
+	if moves := g.GetAllMovesUCI(); len(moves) > 0 {
+		position += " moves " + strings.Join(moves, " ")
+	}
+	if err := e.send(position); err != nil {
+		return SearchResult{}, err
+	}
+	if err := e.send(fmt.Sprintf("go movetime %d", e.config.MoveTime.Milliseconds())); err != nil {
+		return SearchResult{}, err
+	}
+
+	result := SearchResult{}
+	deadline := time.After(e.config.MoveTime + stopGrace)
+	stopped := false
+	for {
+		line, err := e.readLine(deadline)
+		if errors.Is(err, errTimeout) && !stopped {
+			// the engine is late, ask for the move it has now
+			stopped = true
+			deadline = time.After(stopGrace)
+			if err := e.send("stop"); err != nil {
+				return SearchResult{}, err
+			}
+			continue
+		}
+		if errors.Is(err, errTimeout) {
+			logging.Warn("uci engine not answering, restarting",
+				zap.String("engine", e.name),
+			)
+			if err := e.restart(); err != nil {
+				return SearchResult{}, err
+			}
+			return SearchResult{}, errors.New("uci engine exceeded the move time")
+		}
+		if err != nil {
+			return SearchResult{}, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "info":
+			parseInfo(fields[1:], &result)
+		case "bestmove":
+			if len(fields) < 2 || fields[1] == "(none)" {
+				return SearchResult{}, errors.New("uci engine found no move")
+			}
+			move, err := fromUCI(g, fields[1])
+			if err != nil {
+				return SearchResult{}, fmt.Errorf("uci engine move %s: %w", fields[1], err)
+			}
+			result.Move = move
+			return result, nil
+		}
+	}
+}
+
+// tell the engine which variant it plays, if it changed since the last search
+func (e *UCIEngine) setVariant(variant string) error {
+	if variant == e.variant {
+		return nil
+	}
+	switch variant {
+	case "standard", "chess960":
+		if e.options["UCI_Chess960"] {
+			if err := e.setOption("UCI_Chess960", strconv.FormatBool(variant == "chess960")); err != nil {
+				return err
+			}
+		} else if variant == "chess960" {
+			return errors.New("uci engine doesn't support chess960")
+		}
+		if e.options["UCI_Variant"] {
+			if err := e.setOption("UCI_Variant", "chess"); err != nil {
+				return err
+			}
+		}
+	default:
+		name, ok := uciVariants[variant]
+		if !ok || !e.options["UCI_Variant"] {
+			return fmt.Errorf("uci engine doesn't support %s", variant)
+		}
+		if err := e.setOption("UCI_Variant", name); err != nil {
+			return err
+		}
+	}
+	if err := e.send("ucinewgame"); err != nil {
+		return err
+	}
+	if err := e.ready(); err != nil {
+		return err
+	}
+	e.variant = variant
+	return nil
+}
+
+func (e *UCIEngine) setOption(name, value string) error {
+	return e.send("setoption name " + name + " value " + value)
+}
+
+func (e *UCIEngine) send(command string) error {
+	if _, err := io.WriteString(e.stdin, command+"\n"); err != nil {
+		return fmt.Errorf("%w: %v", errEngineExited, err)
+	}
+	return nil
+}
+
+var errTimeout = errors.New("uci engine timeout")
+
+func (e *UCIEngine) readLine(deadline <-chan time.Time) (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", errEngineExited
+		}
+		return line, nil
+	case <-deadline:
+		return "", errTimeout
+	}
+}
+
+// update the result with an "info" line of the main line, e.g.
+// "depth 12 seldepth 15 multipv 1 score cp 34 nodes 12000 pv e2e4 e7e5"
+func parseInfo(fields []string, result *SearchResult) {
+	info := SearchResult{Depth: result.Depth, Score: result.Score, Mate: result.Mate, Nodes: result.Nodes, PV: result.PV}
+	for i := 0; i < len(fields); i++ {
+		next := func() int {
+			if i+1 >= len(fields) {
+				return 0
+			}
+			i++
+			n, _ := strconv.Atoi(fields[i])
+			return n
+		}
+		switch fields[i] {
+		case "multipv":
+			if next() != 1 {
+				return
+			}
+		case "depth":
+			info.Depth = next()
+		case "nodes":
+			info.Nodes = next()
+		case "score":
+			if i+2 >= len(fields) {
+				return
+			}
+			i++
+			switch fields[i] {
+			case "cp":
+				info.Score, info.Mate = next(), 0
+			case "mate":
+				info.Mate = next()
+				info.Score = mateScoreIn(info.Mate)
+			}
+		case "pv":
+			info.PV = append([]string{}, fields[i+1:]...)
+			i = len(fields)
+		case "string":
+			// free text until the end of the line
+			return
+		}
+	}
+	*result = info
+}
+
+// the move of the engine in the form of "e2-e4", or "N@f3" for a drop
+func fromUCI(g *game.Game, move string) (string, error) {
+	startPos, endPos, err := g.ResolveMove(move)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(startPos, "@") {
+		return startPos + endPos, nil
+	}
+	return startPos + "-" + endPos, nil
+}
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+func newFakeUCIEngine(t *testing.T, moveTime time.Duration) *UCIEngine {
+	e, err := NewUCIEngine(UCIConfig{
+		Path:     "/bin/sh",
+		Args:     []string{"testdata/fake_uci.sh"},
+		MoveTime: moveTime,
+		Options:  map[string]string{"Skill Level": "5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func playMoves(t *testing.T, g *game.Game, moves ...string) {
+	for _, m := range moves {
+		if err := play(g, m); err != nil {
+			t.Fatalf("couldn't play %s: %v", m, err)
+		}
+	}
+}
+
+func TestUCIEngine(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "commands")
+	t.Setenv("FAKE_UCI_LOG", logFile)
+	t.Setenv("FAKE_UCI_BESTMOVE", "g1f3")
+	e := newFakeUCIEngine(t, 10*time.Millisecond)
+	if e.Name() != "Fake Engine" {
+		t.Errorf("Test uci engine: got name %q", e.Name())
+	}
+
+	igame := game.InitGame([2]string{"white", "black"})
+	playMoves(t, igame, "e2-e4", "e7-e5")
+	result, err := e.Search(igame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SearchResult{Move: "g1-f3", Score: 35, Depth: 2, Nodes: 400, PV: []string{"g1f3", "e7e5"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Test uci engine: got %+v, want %+v", result, want)
+	}
+
+	commands, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{
+		"setoption name Skill Level value 5",
+		"position fen " + game.StartingFEN + " moves e2e4 e7e5",
+		"go movetime 10",
+	} {
+		if !strings.Contains(string(commands), command+"\n") {
+			t.Errorf("Test uci engine: command %q not sent in\n%s", command, commands)
+		}
+	}
+}
+
+func TestUCIEngineMoves(t *testing.T) {
+	t.Setenv("FAKE_UCI_BESTMOVE", "e1g1")
+	t.Setenv("FAKE_UCI_SCORE", "mate -2")
+	e := newFakeUCIEngine(t, 10*time.Millisecond)
+
+	// castling is sent as the king's destination and played as the king taking its rook
+	igame, err := game.InitGameFromFEN([2]string{"white", "black"}, "4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := e.Search(igame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Move != "e1-h1" || result.Mate != -2 || result.Score != mateScoreIn(-2) {
+		t.Errorf("Test uci engine moves: got %+v", result)
+	}
+
+	crazyhouse, err := game.InitGameVariant([2]string{"white", "black"}, game.Crazyhouse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Search(crazyhouse); err == nil {
+		t.Error("Test uci engine moves: want error for a variant the engine doesn't support")
+	}
+}
+
+func TestUCIEngineCrash(t *testing.T) {
+	crashFile := filepath.Join(t.TempDir(), "crash")
+	if err := os.WriteFile(crashFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_UCI_CRASH", crashFile)
+	e := newFakeUCIEngine(t, 10*time.Millisecond)
+
+	// the engine crashes on its first search, is restarted and searches again
+	move, err := e.BestMove(game.InitGame([2]string{"white", "black"}))
+	if err != nil || move != "e2-e4" {
+		t.Errorf("Test uci engine crash: got %s, %v", move, err)
+	}
+}
+
+func TestUCIEngineTimeout(t *testing.T) {
+	defer func(grace time.Duration) { stopGrace = grace }(stopGrace)
+	stopGrace = 50 * time.Millisecond
+	t.Setenv("FAKE_UCI_HANG", "1")
+	e := newFakeUCIEngine(t, 10*time.Millisecond)
+
+	start := time.Now()
+	if _, err := e.Search(game.InitGame([2]string{"white", "black"})); err == nil {
+		t.Error("Test uci engine timeout: want error for an engine not answering")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Test uci engine timeout: took %v", elapsed)
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	result := SearchResult{Depth: 3, Score: 10}
+	parseInfo(strings.Fields("currmove e2e4 currmovenumber 1"), &result)
+	parseInfo(strings.Fields("depth 4 multipv 2 score cp 50 pv d2d4"), &result)
+	if result.Depth != 3 || result.Score != 10 {
+		t.Errorf("Test parse info: got %+v, want the main line kept", result)
+	}
+	parseInfo(strings.Fields("depth 5 score mate 3 lowerbound nodes 9 pv h5f7"), &result)
+	want := SearchResult{Depth: 5, Score: mateScoreIn(3), Mate: 3, Nodes: 9, PV: []string{"h5f7"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Test parse info: got %+v, want %+v", result, want)
+	}
+}
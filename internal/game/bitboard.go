@@ -0,0 +1,98 @@
+package game
+
+import "math/bits"
+
+// set of squares, bit y*8+x stands for the square (x, y), a1 being bit 0 and h8 bit 63
+type bitboard uint64
+
+const (
+	rank1       bitboard = 0xff
+	rank8       bitboard = rank1 << 56
+	darkSquares bitboard = 0xaa55aa55aa55aa55 // a1, c1, ..., b2, d2, ...
+)
+
+func squareBit(sq int) bitboard {
+	return 1 << sq
+}
+
+func squareIndex(x, y int) int {
+	return y*8 + x
+}
+
+// index of the lowest set square
+func (b bitboard) first() int {
+	return bits.TrailingZeros64(uint64(b))
+}
+
+func (b bitboard) count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// sliding directions, the first four go towards higher square indexes
+var rayDirections = [8][2]int{
+	{0, 1}, {1, 1}, {1, 0}, {-1, 1},
+	{0, -1}, {-1, -1}, {-1, 0}, {1, -1},
+}
+
+// precomputed attack tables
+var (
+	knightAttacks [64]bitboard
+	kingAttacks   [64]bitboard
+	pawnAttacks   [2][64]bitboard // indexed by color, white first
+	rays          [8][64]bitboard // squares from a square to the edge in each direction
+)
+
+func init() {
+	for sq := 0; sq < 64; sq++ {
+		x, y := sq%8, sq/8
+
+		for _, d := range [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}} {
+			knightAttacks[sq] |= offsetBit(x+d[0], y+d[1])
+		}
+		for _, d := range rayDirections {
+			kingAttacks[sq] |= offsetBit(x+d[0], y+d[1])
+		}
+		pawnAttacks[0][sq] = offsetBit(x-1, y+1) | offsetBit(x+1, y+1)
+		pawnAttacks[1][sq] = offsetBit(x-1, y-1) | offsetBit(x+1, y-1)
+
+		for dir, d := range rayDirections {
+			for i := 1; i < 8; i++ {
+				rays[dir][sq] |= offsetBit(x+d[0]*i, y+d[1]*i)
+			}
+		}
+	}
+}
+
+// bit of the square (x, y), empty if it's off the board
+func offsetBit(x, y int) bitboard {
+	if x < 0 || x > 7 || y < 0 || y > 7 {
+		return 0
+	}
+	return squareBit(squareIndex(x, y))
+}
+
+// squares attacked along a ray, up to and including the first blocker
+func rayAttacks(dir, sq int, occupied bitboard) bitboard {
+	attacks := rays[dir][sq]
+	blockers := attacks & occupied
+	if blockers == 0 {
+		return attacks
+	}
+	var blocker int
+	if dir < 4 {
+		blocker = blockers.first()
+	} else {
+		blocker = 63 - bits.LeadingZeros64(uint64(blockers))
+	}
+	return attacks ^ rays[dir][blocker]
+}
+
+func rookAttacks(sq int, occupied bitboard) bitboard {
+	return rayAttacks(0, sq, occupied) | rayAttacks(2, sq, occupied) |
+		rayAttacks(4, sq, occupied) | rayAttacks(6, sq, occupied)
+}
+
+func bishopAttacks(sq int, occupied bitboard) bitboard {
+	return rayAttacks(1, sq, occupied) | rayAttacks(3, sq, occupied) |
+		rayAttacks(5, sq, occupied) | rayAttacks(7, sq, occupied)
+}
@@ -0,0 +1,165 @@
+package game
+
+import "testing"
+
+var benchmarkFENs = []struct {
+	name string
+	fen  string
+}{
+	{"Starting position", StartingFEN},
+	{"Kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"},
+	{"Endgame", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1"},
+	{"Promotions", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1"},
+}
+
+// checkmate detection on the mailbox board, the way it was done before the bitboard position
+func mailboxKingInCheck(g *Game) bool {
+	g.updateKingSpots()
+	kingSpot := g.kingSpots[1]
+	if g.isWhiteTurn {
+		kingSpot = g.kingSpots[0]
+	}
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			box := g.board.boxes[i][j]
+			if box.piece == nil || box.piece.isWhite() == g.isWhiteTurn {
+				continue
+			}
+			if box.piece.canMove(g.board, box, kingSpot) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func mailboxIsLegalMove(g *Game, start, end *spot) bool {
+	srcPiece := start.piece
+	if srcPiece == nil || srcPiece.isWhite() != g.isWhiteTurn {
+		return false
+	}
+
+	var enpassantSpot *spot
+	switch p := srcPiece.(type) {
+	case *pawn:
+		if !p.canMove(g.board, start, end) {
+			if !p.canEnpassant(start, end, g.GetLastMove()) {
+				return false
+			}
+			enpassantSpot = g.board.boxes[end.x][start.y]
+		}
+	case *king:
+		if r, ok := end.piece.(*rook); ok && r.isWhite() == p.isWhite() && isCastlingMove(start.x, start.y, end.x, end.y) {
+			return p.canCastling(g.board, start, end)
+		}
+		if !p.canMove(g.board, start, end) {
+			return false
+		}
+	default:
+		if !p.canMove(g.board, start, end) {
+			return false
+		}
+	}
+
+	dstPiece := end.piece
+	end.piece = srcPiece
+	start.piece = nil
+	var enpassantPiece piece
+	if enpassantSpot != nil {
+		enpassantPiece = enpassantSpot.piece
+		enpassantSpot.piece = nil
+	}
+
+	inCheck := mailboxKingInCheck(g)
+
+	start.piece = srcPiece
+	end.piece = dstPiece
+	if enpassantSpot != nil {
+		enpassantSpot.piece = enpassantPiece
+	}
+
+	return !inCheck
+}
+
+// call fn for every (start, end) pair of the board until it returns false
+func forEachSpotPair(g *Game, fn func(start, end *spot) bool) {
+	for i := 0; i < 64; i++ {
+		for j := 0; j < 64; j++ {
+			if !fn(g.board.boxes[i%8][i/8], g.board.boxes[j%8][j/8]) {
+				return
+			}
+		}
+	}
+}
+
+func mailboxHasLegalMove(g *Game) bool {
+	found := false
+	forEachSpotPair(g, func(start, end *spot) bool {
+		found = mailboxIsLegalMove(g, start, end)
+		return !found
+	})
+	return found
+}
+
+func TestBitboardMatchesMailbox(t *testing.T) {
+	for _, tt := range benchmarkFENs {
+		t.Run(tt.name, func(t *testing.T) {
+			igame, err := InitGameFromFEN(generatePlayerIds(), tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			forEachSpotPair(igame, func(start, end *spot) bool {
+				want := mailboxIsLegalMove(igame, start, end)
+				if got := igame.isLegalMove(start, end); got != want {
+					t.Errorf("%s-%s: got legal %v, want %v",
+						mapCoordToChessPos(start.x, start.y), mapCoordToChessPos(end.x, end.y), got, want)
+				}
+				return true
+			})
+		})
+	}
+}
+
+func BenchmarkLegalMoveGeneration(b *testing.B) {
+	for _, tt := range benchmarkFENs {
+		igame, err := InitGameFromFEN(generatePlayerIds(), tt.fen)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(tt.name+"/bitboard", func(b *testing.B) {
+			for range b.N {
+				newPosition(igame).legalMoves()
+			}
+		})
+		b.Run(tt.name+"/mailbox", func(b *testing.B) {
+			for range b.N {
+				forEachSpotPair(igame, func(start, end *spot) bool {
+					mailboxIsLegalMove(igame, start, end)
+					return true
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkCheckmateDetection(b *testing.B) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("bitboard", func(b *testing.B) {
+		for range b.N {
+			igame.pos = nil
+			if !igame.kingInCheckmate() {
+				b.Fatal("want checkmate")
+			}
+		}
+	})
+	b.Run("mailbox", func(b *testing.B) {
+		for range b.N {
+			if !mailboxKingInCheck(igame) || mailboxHasLegalMove(igame) {
+				b.Fatal("want checkmate")
+			}
+		}
+	})
+}
@@ -12,7 +12,7 @@ type board struct {
 	boxes [8][8]*spot
 }
 
-func initBoard() *board {
+func emptyBoard() *board {
 	b := &board{}
 
 	// Set spots for each box in the board
@@ -26,6 +26,12 @@ func initBoard() *board {
 		}
 	}
 
+	return b
+}
+
+func initBoard() *board {
+	b := emptyBoard()
+
 	// Set pieces to their initial positions on the board
 	b.boxes[0][0].piece = &rook{white: true}
 	b.boxes[1][0].piece = &knight{white: true}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// knight placements on the five squares left after the bishops and the queen
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// white back rank of a Chess960 starting position, e.g. "RNBQKBNR" for 518,
+// positions being numbered from 0 to 959 as in the Scharnagl scheme
+func chess960BackRank(n int) (string, error) {
+	if n < 0 || n >= 960 {
+		return "", fmt.Errorf("invalid chess960 position: %d", n)
+	}
+
+	rank := [8]byte{}
+	rank[2*(n%4)+1] = 'B'
+	n /= 4
+	rank[2*(n%4)] = 'B'
+	n /= 4
+
+	// the remaining pieces go on the empty squares from the a file on
+	empty := func(i int) int {
+		for x := range rank {
+			if rank[x] != 0 {
+				continue
+			}
+			if i == 0 {
+				return x
+			}
+			i--
+		}
+		return -1
+	}
+
+	rank[empty(n%6)] = 'Q'
+	n /= 6
+	knights := chess960Knights[n]
+	// the second knight is counted among the squares left after the first
+	rank[empty(knights[0])] = 'N'
+	rank[empty(knights[1]-1)] = 'N'
+
+	// the king goes between the rooks
+	for _, p := range []byte("RKR") {
+		rank[empty(0)] = p
+	}
+
+	return string(rank[:]), nil
+}
+
+/*
+Initialize a game from the Chess960 starting position with the given number,
+from 0 to 959. The king castles with either rook, landing on the g or c file
+with the rook next to it as in standard chess
+*/
+func InitGame960(playerIds [2]string, n int) (*Game, error) {
+	fen, err := chess960FEN(n)
+	if err != nil {
+		return nil, err
+	}
+	return initVariantGame(playerIds, Chess960, fen)
+}
+
+// FEN of a Chess960 starting position, the castling rights given by the files of the rooks
+func chess960FEN(n int) (string, error) {
+	backRank, err := chess960BackRank(n)
+	if err != nil {
+		return "", err
+	}
+
+	rookFiles := ""
+	for x := len(backRank) - 1; x >= 0; x-- {
+		if backRank[x] == 'R' {
+			rookFiles += string(rune('A' + x))
+		}
+	}
+	return fmt.Sprintf("%s/pppppppp/8/8/8/8/PPPPPPPP/%s w %s%s - 0 1",
+		strings.ToLower(backRank), backRank, rookFiles, strings.ToLower(rookFiles)), nil
+}
+
+/*
+Report whether the game follows the Chess960 castling rules
+*/
+func (g *Game) IsChess960() bool {
+	return g.chess960
+}
+
+/*
+Return the FEN of the position the game started from
+*/
+func (g *Game) StartFEN() string {
+	if g.startFEN == "" {
+		return StartingFEN
+	}
+	return g.startFEN
+}
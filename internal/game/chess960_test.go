@@ -0,0 +1,136 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChess960BackRank(t *testing.T) {
+	for n, want := range map[int]string{0: "BBQNNRKR", 518: "RNBQKBNR", 959: "RKRNNQBB"} {
+		if got, _ := chess960BackRank(n); got != want {
+			t.Errorf("Test chess960 back rank: got %s for %d, want %s", got, n, want)
+		}
+	}
+
+	seen := map[string]bool{}
+	for n := 0; n < 960; n++ {
+		rank, err := chess960BackRank(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[rank] = true
+
+		bishops := strings.Index(rank, "B") + strings.LastIndex(rank, "B")
+		king := strings.Index(rank, "K")
+		if bishops%2 == 0 || king < strings.Index(rank, "R") || king > strings.LastIndex(rank, "R") {
+			t.Errorf("Test chess960 back rank: invalid position %d: %s", n, rank)
+		}
+	}
+	if len(seen) != 960 {
+		t.Errorf("Test chess960 back rank: got %d distinct positions, want 960", len(seen))
+	}
+
+	for _, n := range []int{-1, 960} {
+		if _, err := InitGame960(generatePlayerIds(), n); err == nil {
+			t.Errorf("Test chess960 back rank: want error for %d", n)
+		}
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	igame, err := InitGame960(generatePlayerIds(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fen := igame.FEN(); fen != "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1" {
+		t.Errorf("Test chess960 castling: got start fen %s", fen)
+	}
+
+	// the king already stands on g1, castling only moves the rook from h1 to f1 after Rf1 is gone
+	p1, p2 := igame.GetPlayerIds()
+	for _, m := range []struct{ player, start, end string }{
+		{p1, "g2", "g3"}, {p2, "g7", "g6"}, {p1, "e1", "d3"}, {p2, "e8", "d6"},
+		{p1, "f1", "e1"}, {p2, "f8", "e8"},
+	} {
+		if err := igame.MakeMove(m.player, m.start, m.end); err != nil {
+			t.Fatal(err)
+		}
+	}
+	startPos, endPos, err := igame.ParseSAN("O-O")
+	if err != nil || startPos != "g1" || endPos != "h1" {
+		t.Fatalf("Test chess960 castling: got %s-%s, %v for O-O", startPos, endPos, err)
+	}
+	if err := igame.MakeMove(p1, startPos, endPos); err != nil {
+		t.Fatal(err)
+	}
+	fen := "bbqnr1kr/pppppp1p/3n2p1/8/8/3N2P1/PPPPPP1P/BBQNRRK1 b h - 5 4"
+	if got := igame.FEN(); got != fen {
+		t.Errorf("Test chess960 castling: got %s, want %s", got, fen)
+	}
+	if san := igame.GetAllMovesSAN(); san[len(san)-1] != "O-O" {
+		t.Errorf("Test chess960 castling: got san %s", san[len(san)-1])
+	}
+	if uci := igame.GetAllMovesUCI(); uci[len(uci)-1] != "g1h1" {
+		t.Errorf("Test chess960 castling: got uci %s, want the king-to-rook move", uci[len(uci)-1])
+	}
+
+	if err := igame.UndoMove(); err != nil {
+		t.Fatal(err)
+	}
+	if got := igame.FEN(); got != "bbqnr1kr/pppppp1p/3n2p1/8/8/3N2P1/PPPPPP1P/BBQNR1KR w Hh - 4 4" {
+		t.Errorf("Test chess960 castling: got %s after undo", got)
+	}
+}
+
+func TestChess960QueensideCastling(t *testing.T) {
+	// king on b1 and rook on a1 swap places to c1 and d1
+	igame, err := InitGameFromFEN(generatePlayerIds(), "rk6/8/8/8/8/8/8/RK5R w AHa - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !igame.IsChess960() {
+		t.Fatal("Test chess960 queenside castling: want chess960 from the castling rights")
+	}
+
+	p1, _ := igame.GetPlayerIds()
+	startPos, endPos, err := igame.ResolveMove("O-O-O")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := igame.MakeMove(p1, startPos, endPos); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := igame.FEN(), "rk6/8/8/8/8/8/8/2KR3R b a - 1 1"; got != want {
+		t.Errorf("Test chess960 queenside castling: got %s, want %s", got, want)
+	}
+}
+
+func TestChess960PGN(t *testing.T) {
+	igame, err := InitGame960(generatePlayerIds(), 518)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, _ := igame.GetPlayerIds()
+	if err := igame.MakeMove(p1, "e2", "e4"); err != nil {
+		t.Fatal(err)
+	}
+
+	pgn := igame.PGN(PGNTags{})
+	for _, want := range []string{`[Variant "Chess960"]`, `[FEN "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1"]`} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("Test chess960 pgn: missing %q in\n%s", want, pgn)
+		}
+	}
+
+	games, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := games[0].Replay(generatePlayerIds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replayed.IsChess960() || replayed.FEN() != igame.FEN() {
+		t.Errorf("Test chess960 pgn: got %s, chess960 %v", replayed.FEN(), replayed.IsChess960())
+	}
+}
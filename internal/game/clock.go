@@ -0,0 +1,57 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+End the game on time for the given side. The game is drawn instead
+if the opponent has too little material to ever win under the rules of the variant
+*/
+func (g *Game) Timeout(white bool) error {
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+	switch {
+	case !g.variant.canWin(g.position(), !white):
+		g.status = timeoutVsInsufficientMaterial
+	case white:
+		g.status = whiteTimeout
+	default:
+		g.status = blackTimeout
+	}
+	return nil
+}
+
+/*
+Record the time left on the clock of the player who made the move
+with the given index, counted from 0
+*/
+func (g *Game) SetMoveClock(index int, remaining time.Duration) error {
+	if index < 0 || index >= len(g.moves) {
+		return fmt.Errorf("no move %d to record the clock of", index)
+	}
+	g.moves[index].clock = remaining
+	g.moves[index].hasClock = true
+	return nil
+}
+
+/*
+Return the time left on the clock of the mover after each move,
+zero for the moves played without a clock
+*/
+func (g *Game) MoveClocks() []time.Duration {
+	clocks := make([]time.Duration, 0, len(g.moves))
+	for _, move := range g.moves {
+		clocks = append(clocks, move.clock)
+	}
+	return clocks
+}
+
+// clock comment of the PGN export, e.g. "{[%clk 0:04:58]}"
+func clockComment(remaining time.Duration) string {
+	seconds := int(remaining / time.Second)
+	return fmt.Sprintf("{[%%clk %d:%02d:%02d]}", seconds/3600, seconds/60%60, seconds%60)
+}
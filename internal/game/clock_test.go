@@ -0,0 +1,81 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	igame := InitGame(generatePlayerIds())
+	if err := igame.Timeout(true); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "WHITE_TIMEOUT" || igame.Result() != "0-1" {
+		t.Errorf("Test timeout: got status %s, result %s", igame.GetStatus(), igame.Result())
+	}
+	if err := igame.Timeout(false); err == nil {
+		t.Error("Test timeout: want error for game already over")
+	}
+
+	// a lone king can't win on time
+	for white, want := range map[bool]string{true: "TIMEOUT_VS_INSUFFICIENT_MATERIAL", false: "BLACK_TIMEOUT"} {
+		igame, err := InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/8/4KQ2 w - - 0 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := igame.Timeout(white); err != nil {
+			t.Fatal(err)
+		}
+		if igame.GetStatus() != want {
+			t.Errorf("Test timeout: got status %s, want %s", igame.GetStatus(), want)
+		}
+	}
+}
+
+func TestVariantTimeout(t *testing.T) {
+	// a lone king can still win by reaching the centre or by losing it
+	for _, tc := range []struct {
+		variant Variant
+		want    string
+	}{
+		{Standard, "TIMEOUT_VS_INSUFFICIENT_MATERIAL"},
+		{KingOfTheHill, "WHITE_TIMEOUT"},
+		{Antichess, "WHITE_TIMEOUT"},
+		{ThreeCheck, "TIMEOUT_VS_INSUFFICIENT_MATERIAL"},
+	} {
+		igame, err := initVariantGame(generatePlayerIds(), tc.variant, "4k3/8/8/8/8/8/8/4KQ2 w - - 0 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := igame.Timeout(true); err != nil {
+			t.Fatal(err)
+		}
+		if igame.GetStatus() != tc.want {
+			t.Errorf("Test variant timeout: %s, got status %s, want %s", tc.variant.Title(), igame.GetStatus(), tc.want)
+		}
+	}
+}
+
+func TestMoveClocks(t *testing.T) {
+	igame := InitGame(generatePlayerIds())
+	if err := playSAN(igame, []string{"e4", "e5", "Nf3"}); err != nil {
+		t.Fatal(err)
+	}
+	for i, remaining := range []time.Duration{299 * time.Second, 298500 * time.Millisecond} {
+		if err := igame.SetMoveClock(i, remaining); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := igame.SetMoveClock(3, time.Minute); err == nil {
+		t.Error("Test move clocks: want error for move not played")
+	}
+	if clocks := igame.MoveClocks(); len(clocks) != 3 || clocks[1] != 298500*time.Millisecond || clocks[2] != 0 {
+		t.Errorf("Test move clocks: got %v", clocks)
+	}
+
+	want := "1. e4 {[%clk 0:04:59]} 1... e5 {[%clk 0:04:58]} 2. Nf3 *"
+	if pgn := igame.PGN(PGNTags{}); !strings.Contains(pgn, want) {
+		t.Errorf("Test move clocks: missing %q in\n%s", want, pgn)
+	}
+}
@@ -0,0 +1,264 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+ * Crazyhouse
+ * A captured piece goes to the pocket of the side that took it and can be
+ * dropped back on an empty square instead of moving, e.g. "N@f3"
+ */
+type crazyhouseRules struct {
+	standardRules
+}
+
+func (crazyhouseRules) Name() string         { return "crazyhouse" }
+func (crazyhouseRules) Title() string        { return "Crazyhouse" }
+func (crazyhouseRules) pocketCaptures() bool { return true }
+
+func (crazyhouseRules) startingFEN() string {
+	return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"
+}
+
+func (crazyhouseRules) filterMoves(pos *position, moves []posMove) []posMove {
+	return append(moves, pos.legalDrops()...)
+}
+
+func (crazyhouseRules) terminalStatus(pos *position) GameStatus {
+	// captured pieces come back into play, so material never runs out
+	return pos.mateStatus()
+}
+
+// pieces in hand can still be dropped to mate
+func (crazyhouseRules) canWin(pos *position, white bool) bool {
+	return !pos.insufficientMaterialFor(white) || pos.pockets[colorIndex(white)] != [kindKing]int{}
+}
+
+// letters of the pieces that can be in a pocket, indexed by piece kind
+const pocketLetters = "PNBRQ"
+
+var pocketPieceNames = [kindKing]string{"pawn", "knight", "bishop", "rook", "queen"}
+
+// start of a drop as taken by MakeMove, e.g. "N@" for a knight
+func dropStart(kind pieceKind) string {
+	return pocketLetters[kind:kind+1] + "@"
+}
+
+// piece kind dropped by a start given as "N@", false if startPos isn't a drop
+func parseDropStart(startPos string) (pieceKind, bool) {
+	if len(startPos) != 2 || startPos[1] != '@' {
+		return 0, false
+	}
+	i := strings.IndexByte(pocketLetters, startPos[0])
+	if i < 0 {
+		return 0, false
+	}
+	return pieceKind(i), true
+}
+
+// drop in the form of "N@f3", or "@f3" for a pawn, split into its start and destination square
+func parseDrop(move string) ([]string, bool) {
+	if strings.HasPrefix(move, "@") {
+		move = "P" + move
+	}
+	if len(move) != 4 || !isSquare(move[2:]) {
+		return nil, false
+	}
+	start := strings.ToUpper(move[:2])
+	if _, ok := parseDropStart(start); !ok {
+		return nil, false
+	}
+	return []string{start, move[2:]}, true
+}
+
+/*
+Check if captured pieces go to the capturer's pocket and can be dropped back,
+as in Crazyhouse
+*/
+func (g *Game) HasPockets() bool {
+	return g.variant.pocketCaptures()
+}
+
+/*
+Return the pieces in the pocket of the given side as FEN letters, e.g. "QNP"
+for white or "qnp" for black, from the queen down to the pawns
+*/
+func (g *Game) Pocket(white bool) string {
+	var pocket strings.Builder
+	side := colorIndex(white)
+	for kind := len(pocketLetters) - 1; kind >= 0; kind-- {
+		letter := pocketLetters[kind : kind+1]
+		if !white {
+			letter = strings.ToLower(letter)
+		}
+		pocket.WriteString(strings.Repeat(letter, g.pockets[side][kind]))
+	}
+	return pocket.String()
+}
+
+// split the bracketed pocket off the FEN piece placement, e.g. "...RNBQKBNR[Qn]"
+func (g *Game) parsePockets(placement string) (string, error) {
+	open := strings.IndexByte(placement, '[')
+	if open < 0 {
+		return placement, nil
+	}
+	if !g.variant.pocketCaptures() {
+		return "", fmt.Errorf("invalid fen: no pieces in hand in %s", g.variant.Title())
+	}
+	if !strings.HasSuffix(placement, "]") {
+		return "", errors.New("invalid fen: unterminated pocket")
+	}
+	for _, c := range placement[open+1 : len(placement)-1] {
+		i := strings.IndexRune(pocketLetters, c)
+		side := 0
+		if i < 0 {
+			i = strings.IndexRune(strings.ToLower(pocketLetters), c)
+			side = 1
+		}
+		if i < 0 {
+			return "", fmt.Errorf("invalid fen: unknown piece in pocket %q", c)
+		}
+		g.pockets[side][i]++
+	}
+	return placement[:open], nil
+}
+
+// drop of a piece from the pocket of the side to move on the square endPos
+func (g *Game) newDrop(playerId string, kind pieceKind, endPos string) (*move, error) {
+	startPos := dropStart(kind)
+	if !g.variant.pocketCaptures() {
+		return nil, fmt.Errorf("invalid move: %s%s, no drops in %s", startPos, endPos, g.variant.Title())
+	}
+	if !isSquare(endPos) {
+		return nil, fmt.Errorf("invalid drop: %s%s", startPos, endPos)
+	}
+	if g.pockets[colorIndex(g.isWhiteTurn)][kind] == 0 {
+		return nil, fmt.Errorf("no %s in pocket", pocketPieceNames[kind])
+	}
+
+	x, y := mapChessPosToCoord(endPos)
+	end := g.board.boxes[x][y]
+	if end.piece != nil {
+		return nil, fmt.Errorf("invalid drop: %s%s, square is occupied", startPos, endPos)
+	}
+	if kind == kindPawn && (y == 0 || y == 7) {
+		return nil, fmt.Errorf("invalid drop: %s%s, pawn on first or last rank", startPos, endPos)
+	}
+	if !g.position().isLegalDrop(kind, squareIndex(x, y)) {
+		return nil, fmt.Errorf("invalid drop: %s%s, king in check", startPos, endPos)
+	}
+
+	letter := rune(pocketLetters[kind])
+	if !g.isWhiteTurn {
+		letter += 'a' - 'A'
+	}
+	p, _ := pieceFromFEN(letter)
+	if pw, ok := p.(*pawn); ok {
+		// a pawn dropped on its starting rank can make the 2 step init move
+		pw.initMoved = (pw.white && y != 1) || (!pw.white && y != 6)
+	}
+
+	return &move{
+		playerId:   playerId,
+		startPos:   startPos,
+		endPos:     endPos,
+		end:        end,
+		pieceMoved: p,
+		isDrop:     true,
+	}, nil
+}
+
+// take the dropped piece out of the pocket and put the captured one in the capturer's pocket
+func (g *Game) updatePockets(move *move) {
+	side := colorIndex(move.pieceMoved.isWhite())
+	switch {
+	case move.isDrop:
+		g.pockets[side][kindOf(move.pieceMoved)]--
+	case !g.variant.pocketCaptures() || move.isCastling:
+	case move.isEnpassant:
+		g.pockets[side][kindPawn]++
+	case move.pieceTaken != nil:
+		g.pockets[side][pocketKind(move.pieceTaken)]++
+	}
+}
+
+// kind a captured piece goes to the pocket as, a promoted piece turns back into a pawn
+func pocketKind(p piece) pieceKind {
+	if isPromoted(p) {
+		return kindPawn
+	}
+	return kindOf(p)
+}
+
+func isPromoted(p piece) bool {
+	switch p := p.(type) {
+	case *knight:
+		return p.promoted
+	case *bishop:
+		return p.promoted
+	case *rook:
+		return p.promoted
+	case *queen:
+		return p.promoted
+	}
+	return false
+}
+
+// mark a piece read from a FEN with the "~" suffix, e.g. "Q~", as promoted
+func setPromoted(p piece) bool {
+	switch p := p.(type) {
+	case *knight:
+		p.promoted = true
+	case *bishop:
+		p.promoted = true
+	case *rook:
+		p.promoted = true
+	case *queen:
+		p.promoted = true
+	default:
+		return false
+	}
+	return true
+}
+
+// drops of the pieces in the pocket of the side to move that don't leave the own king in check
+func (pos *position) legalDrops() []posMove {
+	us := pos.side()
+	empty := ^(pos.occupied[0] | pos.occupied[1])
+	inCheck := pos.inCheck()
+
+	drops := []posMove{}
+	for kind := kindPawn; kind < kindKing; kind++ {
+		if pos.pockets[us][kind] == 0 {
+			continue
+		}
+		targets := empty
+		if kind == kindPawn {
+			targets &^= rank1 | rank8
+		}
+		for ; targets != 0; targets &= targets - 1 {
+			m := posMove{to: uint8(targets.first()), drop: true, dropped: kind}
+			// out of check, a drop can't expose the king, in check it has to block
+			if inCheck {
+				if next := pos.play(m); next.kingAttacked(pos.whiteToMove) {
+					continue
+				}
+			}
+			drops = append(drops, m)
+		}
+	}
+	return drops
+}
+
+func (pos *position) isLegalDrop(kind pieceKind, to int) bool {
+	pos.generate()
+	for _, m := range pos.legal {
+		if m.drop && m.dropped == kind && int(m.to) == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package game
+
+import "errors"
+
+// halfmoves without a pawn move or capture after which a draw can be claimed or is forced
+const (
+	fiftyMoveHalfmoves       = 100
+	seventyFiveMoveHalfmoves = 150
+)
+
+// end the game once 75 moves have been played by each side without a pawn move or capture
+func (g *Game) checkMoveRule() {
+	if g.status == active && g.halfmoveClock >= seventyFiveMoveHalfmoves {
+		g.status = seventyFiveMoveRule
+	}
+}
+
+/*
+Return the number of halfmoves since the last pawn move or capture
+*/
+func (g *Game) HalfmoveClock() int {
+	return g.halfmoveClock
+}
+
+/*
+Claim a draw by the fifty-move rule for the player with the given id.
+The claim is valid once 50 moves have been played by each side without a pawn move or capture
+*/
+func (g *Game) ClaimFiftyMoveRule(playerId string) error {
+	if _, err := g.GetPlayerSide(playerId); err != nil {
+		return err
+	}
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+	if g.halfmoveClock < fiftyMoveHalfmoves {
+		return errors.New("fifty moves have not been played without a pawn move or capture")
+	}
+	g.status = fiftyMoveRule
+	return nil
+}
+
+/*
+End the game in a draw agreed by both players
+*/
+func (g *Game) AgreeDraw() error {
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+	g.status = drawAgreement
+	return nil
+}
+
+/*
+Claim a draw for the player with the given id by threefold repetition,
+or by the fifty-move rule if the position hasn't occurred three times
+*/
+func (g *Game) ClaimDraw(playerId string) error {
+	if _, err := g.GetPlayerSide(playerId); err != nil {
+		return err
+	}
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+	if g.RepetitionCount() >= 3 {
+		return g.ClaimThreefoldRepetition(playerId)
+	}
+	if g.halfmoveClock >= fiftyMoveHalfmoves {
+		return g.ClaimFiftyMoveRule(playerId)
+	}
+	return errors.New("no draw to claim: no threefold repetition nor fifty moves without a pawn move or capture")
+}
+
+/*
+Check if the given side has too little material to ever checkmate.
+A lone king can't mate, a single knight can only mate with the help of
+the opponent's non-queen pieces and bishops on one square colour need
+an opponent's knight, pawn or bishop on the other square colour
+*/
+func (pos *position) insufficientMaterialFor(white bool) bool {
+	own, opponent := &pos.pieces[colorIndex(white)], &pos.pieces[1-colorIndex(white)]
+	if own[kindPawn]|own[kindRook]|own[kindQueen] != 0 {
+		return false
+	}
+	knights := own[kindKnight].count()
+	bishopColors := [2]int{ // bishops on dark and light squares
+		(own[kindBishop] & darkSquares).count(),
+		(own[kindBishop] &^ darkSquares).count(),
+	}
+	// opponent pieces other than the king and queens
+	opponentBlockers := (pos.occupied[1-colorIndex(white)] &^ (opponent[kindKing] | opponent[kindQueen])).count()
+	opponentBishopColors := [2]int{
+		(opponent[kindBishop] & darkSquares).count(),
+		(opponent[kindBishop] &^ darkSquares).count(),
+	}
+	opponentKnightsOrPawns := (opponent[kindKnight] | opponent[kindPawn]).count()
+
+	bishops := bishopColors[0] + bishopColors[1]
+	switch {
+	case knights == 0 && bishops == 0:
+		return true
+	case knights == 1 && bishops == 0:
+		return opponentBlockers == 0
+	case knights == 0 && (bishopColors[0] == 0 || bishopColors[1] == 0):
+		// all bishops on one colour
+		color := 0
+		if bishopColors[0] == 0 {
+			color = 1
+		}
+		return opponentKnightsOrPawns == 0 && opponentBishopColors[1-color] == 0
+	default:
+		return false
+	}
+}
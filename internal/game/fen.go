@@ -0,0 +1,384 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FEN of the standard starting position
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+/*
+Initialize a game from a position in Forsyth-Edwards Notation.
+All six fields are required: piece placement, side to move, castling rights,
+en passant target square, halfmove clock and fullmove number
+*/
+func InitGameFromFEN(playerIds [2]string, fen string) (*Game, error) {
+	return initGameFromFEN(playerIds, fen, Standard)
+}
+
+func initGameFromFEN(playerIds [2]string, fen string, v Variant) (*Game, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid fen: expected 6 fields, got %d", len(fields))
+	}
+
+	g := &Game{
+		playerIds: playerIds,
+		status:    active,
+		moves:     []*move{},
+		kingSpots: [2]*spot{},
+		startFEN:  strings.Join(fields, " "),
+
+		castlingRooks: standardCastlingRooks,
+		variant:       v,
+	}
+
+	placement, err := g.parsePockets(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := parsePlacement(placement)
+	if err != nil {
+		return nil, err
+	}
+	g.board = b
+
+	if err := g.setKingSpots(); err != nil {
+		return nil, err
+	}
+
+	switch fields[1] {
+	case "w":
+		g.isWhiteTurn = true
+	case "b":
+		g.isWhiteTurn = false
+	default:
+		return nil, fmt.Errorf("invalid fen: unknown side to move %q", fields[1])
+	}
+
+	if err := g.setCastlingRights(fields[2]); err != nil {
+		return nil, err
+	}
+
+	if err := g.setEnpassantTarget(fields[3]); err != nil {
+		return nil, err
+	}
+
+	g.halfmoveClock, err = strconv.Atoi(fields[4])
+	if err != nil || g.halfmoveClock < 0 {
+		return nil, fmt.Errorf("invalid fen: bad halfmove clock %q", fields[4])
+	}
+	g.fullmoveNumber, err = strconv.Atoi(fields[5])
+	if err != nil || g.fullmoveNumber < 1 {
+		return nil, fmt.Errorf("invalid fen: bad fullmove number %q", fields[5])
+	}
+
+	// the side that just moved can't have left its king in check
+	if g.position().kingAttacked(!g.isWhiteTurn) {
+		return nil, fmt.Errorf("invalid fen: side not to move is in check")
+	}
+
+	g.updateCheckFlags()
+	g.updateStatus()
+	// the position may already be past the automatic move-rule draw
+	g.checkMoveRule()
+	g.initRepetitions()
+
+	return g, nil
+}
+
+func pieceFromFEN(c rune) (piece, error) {
+	white := c >= 'A' && c <= 'Z'
+	switch strings.ToLower(string(c)) {
+	case "p":
+		return &pawn{white: white}, nil
+	case "n":
+		return &knight{white: white}, nil
+	case "b":
+		return &bishop{white: white}, nil
+	case "r":
+		// castling rights are restored later from the castling field
+		return &rook{white: white, initMoved: true}, nil
+	case "q":
+		return &queen{white: white}, nil
+	case "k":
+		return &king{white: white, initMoved: true}, nil
+	default:
+		return nil, fmt.Errorf("invalid fen: unknown piece %q", c)
+	}
+}
+
+func parsePlacement(placement string) (*board, error) {
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("invalid fen: expected 8 ranks, got %d", len(ranks))
+	}
+
+	b := emptyBoard()
+	for i, rank := range ranks {
+		y := 7 - i
+		x := 0
+		for _, c := range rank {
+			if c >= '1' && c <= '8' {
+				x += int(c - '0')
+				continue
+			}
+			if c == '~' {
+				// the mark follows the piece, which must be on the rank
+				if x < 1 || x > 8 || !setPromoted(b.boxes[x-1][y].piece) {
+					return nil, fmt.Errorf("invalid fen: promoted mark without promoted piece on rank %d", y+1)
+				}
+				continue
+			}
+			if x > 7 {
+				return nil, fmt.Errorf("invalid fen: rank %d has more than 8 squares", y+1)
+			}
+			p, err := pieceFromFEN(c)
+			if err != nil {
+				return nil, err
+			}
+			if pw, ok := p.(*pawn); ok {
+				if y == 0 || y == 7 {
+					return nil, fmt.Errorf("invalid fen: pawn on back rank %d", y+1)
+				}
+				// pawns away from their starting rank can't make the 2 step init move
+				pw.initMoved = (pw.white && y != 1) || (!pw.white && y != 6)
+			}
+			b.boxes[x][y].piece = p
+			x++
+		}
+		if x != 8 {
+			return nil, fmt.Errorf("invalid fen: rank %d doesn't have 8 squares", y+1)
+		}
+	}
+
+	return b, nil
+}
+
+func (g *Game) setKingSpots() error {
+	counts := [2]int{}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			if k, ok := g.board.boxes[x][y].piece.(*king); ok {
+				if k.isWhite() {
+					g.kingSpots[0] = g.board.boxes[x][y]
+					counts[0]++
+				} else {
+					g.kingSpots[1] = g.board.boxes[x][y]
+					counts[1]++
+				}
+			}
+		}
+	}
+	// any number of kings is fine when they aren't royal
+	if !g.variant.royalKing() {
+		return nil
+	}
+	if counts[0] != 1 {
+		return fmt.Errorf("invalid fen: expected 1 white king, got %d", counts[0])
+	}
+	if counts[1] != 1 {
+		return fmt.Errorf("invalid fen: expected 1 black king, got %d", counts[1])
+	}
+	return nil
+}
+
+// castling rights from "KQkq", where the rook is the outermost one on that side of the king,
+// or from the rook files as in Shredder-FEN (e.g. "HAha"). Rights off the standard squares
+// make the game Chess960
+func (g *Game) setCastlingRights(castling string) error {
+	if castling == "-" {
+		return nil
+	}
+
+	for _, c := range castling {
+		white := c >= 'A' && c <= 'Z'
+		side, y := 0, 0
+		if !white {
+			side, y = 1, 7
+		}
+
+		kingSpot := g.kingSpots[side]
+		if kingSpot == nil || kingSpot.y != y {
+			return fmt.Errorf("invalid fen: castling right %q without king on its first rank", c)
+		}
+
+		rookX := -1
+		switch c {
+		case 'K', 'k':
+			rookX = g.outermostRook(kingSpot, 7)
+		case 'Q', 'q':
+			rookX = g.outermostRook(kingSpot, 0)
+		default:
+			if lower := c | 0x20; lower >= 'a' && lower <= 'h' {
+				rookX = int(lower - 'a')
+				g.chess960 = true
+			} else {
+				return fmt.Errorf("invalid fen: unknown castling right %q", c)
+			}
+		}
+		if rookX < 0 || rookX == kingSpot.x {
+			return fmt.Errorf("invalid fen: castling right %q without rook", c)
+		}
+		r, ok := g.board.boxes[rookX][y].piece.(*rook)
+		if !ok || r.isWhite() != white {
+			return fmt.Errorf("invalid fen: castling right %q without rook", c)
+		}
+
+		right := 2 * side
+		if rookX < kingSpot.x {
+			right++
+		}
+		g.castlingRooks[right] = rookX
+		if kingSpot.x != 4 || rookX != standardCastlingRooks[right] {
+			g.chess960 = true
+		}
+		kingSpot.piece.(*king).initMoved = false
+		r.initMoved = false
+	}
+
+	return nil
+}
+
+// file of the rook of the king's color farthest from the king towards the edge, -1 if there is none
+func (g *Game) outermostRook(kingSpot *spot, edgeX int) int {
+	step := 1
+	if edgeX < kingSpot.x {
+		step = -1
+	}
+	rookX := -1
+	for x := kingSpot.x + step; x >= 0 && x < 8; x += step {
+		r, ok := g.board.boxes[x][kingSpot.y].piece.(*rook)
+		if ok && r.isWhite() == kingSpot.piece.isWhite() {
+			rookX = x
+		}
+	}
+	return rookX
+}
+
+func (g *Game) setEnpassantTarget(target string) error {
+	if target == "-" {
+		return nil
+	}
+	if !isSquare(target) {
+		return fmt.Errorf("invalid fen: bad en passant square %q", target)
+	}
+
+	x, y := mapChessPosToCoord(target)
+	// the target lies behind a pawn of the side that just moved
+	startY, endY := 1, 3
+	if g.isWhiteTurn {
+		startY, endY = 6, 4
+	}
+	if y != (startY+endY)/2 {
+		return fmt.Errorf("invalid fen: en passant square %s on wrong rank", target)
+	}
+
+	p, ok := g.board.boxes[x][endY].piece.(*pawn)
+	if !ok || p.isWhite() == g.isWhiteTurn {
+		return fmt.Errorf("invalid fen: no pawn in front of en passant square %s", target)
+	}
+	if g.board.boxes[x][y].piece != nil || g.board.boxes[x][startY].piece != nil {
+		return fmt.Errorf("invalid fen: en passant square %s is occupied", target)
+	}
+
+	g.fenLastMove = &move{
+		startPos:   mapCoordToChessPos(x, startY),
+		endPos:     mapCoordToChessPos(x, endY),
+		start:      g.board.boxes[x][startY],
+		end:        g.board.boxes[x][endY],
+		pieceMoved: p,
+		isInitMove: true,
+	}
+
+	return nil
+}
+
+/*
+Return the current position in Forsyth-Edwards Notation
+*/
+func (g *Game) FEN() string {
+	var fen strings.Builder
+
+	for y := 7; y >= 0; y-- {
+		emptyCount := 0
+		for x := 0; x < 8; x++ {
+			p := g.board.boxes[x][y].piece
+			if p == nil {
+				emptyCount++
+				continue
+			}
+			if emptyCount > 0 {
+				fen.WriteString(strconv.Itoa(emptyCount))
+				emptyCount = 0
+			}
+			fen.WriteString(p.toFEN())
+			if g.variant.pocketCaptures() && isPromoted(p) {
+				fen.WriteString("~")
+			}
+		}
+		if emptyCount > 0 {
+			fen.WriteString(strconv.Itoa(emptyCount))
+		}
+		if y > 0 {
+			fen.WriteString("/")
+		}
+	}
+	if g.variant.pocketCaptures() {
+		fen.WriteString("[" + g.Pocket(true) + g.Pocket(false) + "]")
+	}
+
+	if g.isWhiteTurn {
+		fen.WriteString(" w ")
+	} else {
+		fen.WriteString(" b ")
+	}
+
+	fen.WriteString(g.castlingRights())
+	fen.WriteString(" ")
+	fen.WriteString(g.enpassantTarget())
+	fen.WriteString(" ")
+	fen.WriteString(strconv.Itoa(g.halfmoveClock))
+	fen.WriteString(" ")
+	fen.WriteString(strconv.Itoa(g.fullmoveNumber))
+
+	return fen.String()
+}
+
+// castling rights in "KQkq" letters, or as rook files (e.g. "HAha") in Chess960
+func (g *Game) castlingRights() string {
+	rights := ""
+	mask := g.castlingMask()
+	for i, symbol := range "KQkq" {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		if g.chess960 {
+			symbol = rune('A' + g.castlingRooks[i])
+			if i >= 2 {
+				symbol = rune('a' + g.castlingRooks[i])
+			}
+		}
+		rights += string(symbol)
+	}
+	if rights == "" {
+		return "-"
+	}
+	return rights
+}
+
+func (g *Game) enpassantTarget() string {
+	lastMove := g.GetLastMove()
+	if lastMove == nil || !lastMove.isInitMove {
+		return "-"
+	}
+	if _, ok := lastMove.pieceMoved.(*pawn); !ok {
+		return "-"
+	}
+	if dy := lastMove.end.y - lastMove.start.y; dy != 2 && dy != -2 {
+		return "-"
+	}
+	return mapCoordToChessPos(lastMove.end.x, (lastMove.start.y+lastMove.end.y)/2)
+}
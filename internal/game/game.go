@@ -3,8 +3,6 @@ package game
 import (
 	"errors"
 	"fmt"
-
-	"github.com/yelaco/go-chess-server/pkg/logging"
 )
 
 type GameStatus string
@@ -16,17 +14,49 @@ const (
 	stalemate      GameStatus = "STALEMATE"
 	blackResign    GameStatus = "BLACK_RESIGN"
 	whiteResign    GameStatus = "WHITE_RESIGN"
+
+	threefoldRepetition  GameStatus = "THREEFOLD_REPETITION"
+	fivefoldRepetition   GameStatus = "FIVEFOLD_REPETITION"
+	fiftyMoveRule        GameStatus = "FIFTY_MOVE_RULE"
+	seventyFiveMoveRule  GameStatus = "SEVENTY_FIVE_MOVE_RULE"
+	insufficientMaterial GameStatus = "INSUFFICIENT_MATERIAL"
+	drawAgreement        GameStatus = "DRAW_AGREEMENT"
+
+	// flag fall, named after the side that ran out of time
+	whiteTimeout                  GameStatus = "WHITE_TIMEOUT"
+	blackTimeout                  GameStatus = "BLACK_TIMEOUT"
+	timeoutVsInsufficientMaterial GameStatus = "TIMEOUT_VS_INSUFFICIENT_MATERIAL"
+
+	// results recorded without a reason the game can tell, e.g. in an imported game
+	whiteWins GameStatus = "WHITE_WINS"
+	blackWins GameStatus = "BLACK_WINS"
+	draw      GameStatus = "DRAW"
 )
 
 type Game struct {
-	playerIds   [2]string
-	isWhiteTurn bool
-	board       *board
-	moves       []*move // moves playied through out the game
-	status      GameStatus
-	kingSpots   [2]*spot
+	playerIds      [2]string
+	isWhiteTurn    bool
+	board          *board
+	moves          []*move // moves playied through out the game
+	status         GameStatus
+	kingSpots      [2]*spot
+	fenLastMove    *move // double pawn push implied by the en passant field of a loaded FEN
+	halfmoveClock  int
+	fullmoveNumber int
+	hash           uint64         // zobrist hash of the current position
+	repetitions    map[uint64]int // number of times each position has occurred
+	pos            *position      // bitboard copy of the position, nil once the board has changed
+	startFEN       string         // position the game started from, empty for the standard one
+	chess960       bool           // castling follows the Chess960 rules, written as king-to-rook in UCI
+	castlingRooks  [4]int         // file of the rook of each castling right, in the order of castlingMask
+	variant        Variant
+	checks         [2]int           // checks given by white and black
+	pockets        [2][kindKing]int // pieces in hand of white and black by kind, for drops
 }
 
+// rook files of the castling rights in the standard starting position
+var standardCastlingRooks = [4]int{7, 0, 7, 0}
+
 func InitGame(playerIds [2]string) *Game {
 	g := &Game{
 		playerIds:   playerIds,
@@ -35,12 +65,70 @@ func InitGame(playerIds [2]string) *Game {
 		status:      active,
 		moves:       []*move{},
 		kingSpots:   [2]*spot{},
+
+		fullmoveNumber: 1,
+		castlingRooks:  standardCastlingRooks,
+		variant:        Standard,
 	}
 	g.kingSpots[0] = g.board.boxes[4][0]
 	g.kingSpots[1] = g.board.boxes[4][7]
+	g.initRepetitions()
 	return g
 }
 
+/*
+Replay the moves of a stored game of the named variant, e.g. "e2-e4", from the position given in FEN,
+or from the starting position of the variant if startFEN is empty.
+A final status the moves don't lead to by themselves, like a resignation,
+is applied once all moves are played
+*/
+func RestoreGame(playerIds [2]string, variant, startFEN string, moves []string, status string) (*Game, error) {
+	v, err := VariantByName(variant)
+	if err != nil {
+		return nil, err
+	}
+	g, err := initVariantGame(playerIds, v, startFEN)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range moves {
+		pos, err := ParseMove(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid move %d: %s", i+1, m)
+		}
+		if err := g.MakeMove(g.currentPlayerId(), pos[0], pos[1]); err != nil {
+			return nil, fmt.Errorf("invalid move %d: %w", i+1, err)
+		}
+	}
+
+	if status != "" && GameStatus(status) != g.status {
+		if _, ok := g.statusResult(GameStatus(status)); !ok {
+			return nil, fmt.Errorf("invalid game status: %s", status)
+		}
+		if g.IsOver() {
+			return nil, fmt.Errorf("game status %s doesn't match the moves: %s", status, g.status)
+		}
+		g.status = GameStatus(status)
+	}
+
+	return g, nil
+}
+
+/*
+Return a copy of the game replayed from its starting position, so it can be
+played on, e.g. by a search, without touching the original
+*/
+func (g *Game) Clone() (*Game, error) {
+	clone, err := RestoreGame(g.playerIds, g.variant.Name(), g.startFEN, g.GetAllMoves(), string(g.status))
+	if err != nil {
+		return nil, err
+	}
+	for i, move := range g.moves {
+		clone.moves[i].clock, clone.moves[i].hasClock = move.clock, move.hasClock
+	}
+	return clone, nil
+}
+
 func (g *Game) GetBoard() [8][8]string {
 	boxes := [8][8]string{}
 	for i := 7; i >= 0; i-- {
@@ -83,243 +171,111 @@ func (g *Game) IsOver() bool {
 	return g.status != active
 }
 
+/*
+Resign the game for the player with the given id, whoever's turn it is
+*/
+func (g *Game) Resign(playerId string) error {
+	isWhiteSide, err := g.GetPlayerSide(playerId)
+	if err != nil {
+		return err
+	}
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+	if isWhiteSide {
+		g.status = whiteResign
+	} else {
+		g.status = blackResign
+	}
+	return nil
+}
+
 func (g *Game) checkAndNextTurn(move *move) {
 	// go to next turn
 	g.isWhiteTurn = !g.isWhiteTurn
+	g.pos = nil
 
-	if g.isStalemate() {
-		g.status = stalemate
-	} else if g.kingInCheck() {
-		if g.kingInCheckmate() {
-			if g.isWhiteTurn {
-				g.status = blackCheckmate
-			} else {
-				g.status = whiteCheckmate
-			}
-		}
-
-		move.isChecking = true
+	move.isChecking = g.updateCheckFlags()
+	if move.isChecking {
+		g.checks[colorIndex(!g.isWhiteTurn)]++
+		// the position was built to detect the check, before it was counted
+		g.position().checks = g.checks
 	}
+	g.updateStatus()
 }
 
-func (g *Game) updateKingSpots() {
-	wk, isWk := g.kingSpots[0].piece.(*king)
-	bk, isBk := g.kingSpots[1].piece.(*king)
-	if isWk && isBk && wk.isWhite() && !bk.isWhite() {
-		return
-	}
-
-	for x := 0; x < 8; x++ {
-		for y := 0; y < 8; y++ {
-			if k, ok := g.board.boxes[x][y].piece.(*king); ok {
-				if k.isWhite() {
-					g.kingSpots[0] = g.board.boxes[x][y]
-				} else {
-					g.kingSpots[1] = g.board.boxes[x][y]
-				}
-			}
-		}
+// end the game if the rules of the variant say so for the side to move
+func (g *Game) updateStatus() {
+	if status := g.variant.terminalStatus(g.position()); status != active {
+		g.status = status
 	}
 }
 
-func (g *Game) kingInCheck() bool {
+// mark the king of the side to move as in check or not and report it
+func (g *Game) updateCheckFlags() bool {
 	g.updateKingSpots()
-	var kingSpot *spot
-	if g.isWhiteTurn {
-		kingSpot = g.kingSpots[0]
-	} else {
-		kingSpot = g.kingSpots[1]
-	}
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			box := g.board.boxes[i][j]
-			if box.piece == nil {
-				continue
-			}
-			if box.piece.isWhite() == g.isWhiteTurn {
-				continue
-			}
-			if box.piece.canMove(g.board, box, kingSpot) {
-				if k, ok := kingSpot.piece.(*king); ok {
-					k.inCheck = true
-					return true
-				}
-			}
+	inCheck := g.kingInCheck()
+	for _, kingSpot := range g.kingSpots {
+		// a side may have no king in variants where it can be taken
+		if kingSpot == nil {
+			continue
+		}
+		if k, ok := kingSpot.piece.(*king); ok {
+			k.inCheck = inCheck && k.isWhite() == g.isWhiteTurn
 		}
 	}
-	return false
+	return inCheck
 }
 
-func (g *Game) kingInCheckmate() bool {
-	threatPieceSpots := []*spot{}
-	var kingSpot *spot
-	if g.isWhiteTurn {
-		kingSpot = g.kingSpots[0]
+// halfmove clock resets on pawn moves and captures, fullmove number increases after black's move
+func (g *Game) updateMoveCounters(move *move) {
+	_, isPawn := move.pieceMoved.(*pawn)
+	if isPawn || (move.pieceTaken != nil && !move.isCastling) {
+		g.halfmoveClock = 0
 	} else {
-		kingSpot = g.kingSpots[1]
+		g.halfmoveClock++
 	}
-
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			box := g.board.boxes[i][j]
-			if box.piece == nil {
-				continue
-			}
-			if box.piece.isWhite() == g.isWhiteTurn {
-				continue
-			}
-			if box.piece.canMove(g.board, box, kingSpot) {
-				threatPieceSpots = append(threatPieceSpots, box)
-			}
-		}
-	}
-
-	king, ok := kingSpot.piece.(*king)
-	if !ok {
-		logging.Error("kingInCheckmate(): king not in spot")
-	}
-
-	// if there are multiple threats, the King must run (can't block attack)
-	// we check if the King can run or not with any number of threats
-	for i := kingSpot.x - 1; i <= kingSpot.x+1; i++ {
-		for j := kingSpot.y - 1; j <= kingSpot.y+1; j++ {
-			if i < 0 || i > 7 || j < 0 || j > 7 {
-				continue
-			}
-			if king.canMove(g.board, kingSpot, g.board.boxes[i][j]) {
-				return false
-			}
-		}
-	}
-
-	// the king can't run and there are multiple checking pieces
-	// ==> can't block
-	if len(threatPieceSpots) > 1 {
-		return true
-	}
-
-	threatSpot := threatPieceSpots[0]
-
-	// if there is a single threat and the King can't run, the threatening piece must be stopped
-	// first by eliminate the threatening piece
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			box := g.board.boxes[i][j]
-			if box.piece == nil {
-				continue
-			}
-			if box.piece.isWhite() != g.isWhiteTurn {
-				continue
-			}
-			if box.piece.canMove(g.board, box, threatSpot) {
-				return false
-			}
-		}
-	}
-
-	// second by block the attack
-	// with knight or pawn checking, there aren't any spots in between to block the attack
-	if _, ok := threatSpot.piece.(*knight); ok {
-		return true
-	}
-	if _, ok := threatSpot.piece.(*pawn); ok {
-		return true
-	}
-
-	// get pieces in between the threat piece and the king
-	inBetweens := make([]*spot, 0, 6)
-	i := threatSpot.x
-	j := threatSpot.y
-	bex := kingSpot.x
-	bey := kingSpot.y
-	if i < bex {
-		bex--
-	} else if i > bex {
-		bex++
-	}
-	if j < bey {
-		bey--
-	} else if j > bey {
-		bey++
+	if !g.isWhiteTurn {
+		g.fullmoveNumber++
 	}
+}
 
-	for i != bex || j != bey {
-		if i < bex {
-			i++
-		} else if i > bex {
-			i--
-		}
-		if j < bey {
-			j++
-		} else if j > bey {
-			j--
+func (g *Game) updateKingSpots() {
+	if g.kingSpots[0] != nil && g.kingSpots[1] != nil {
+		wk, isWk := g.kingSpots[0].piece.(*king)
+		bk, isBk := g.kingSpots[1].piece.(*king)
+		if isWk && isBk && wk.isWhite() && !bk.isWhite() {
+			return
 		}
-
-		inBetweens = append(inBetweens, g.board.boxes[i][j])
 	}
 
-	// check if the check can be blocked
-	for _, theSpot := range inBetweens {
-		for x := 0; x < 8; x++ {
-			for y := 0; y < 8; y++ {
-				box := g.board.boxes[x][y]
-				if box.piece == nil {
-					continue
-				}
-				if box.piece.isWhite() != king.isWhite() {
-					continue
-				}
-				if box.piece.canMove(g.board, box, theSpot) {
-					return false
+	g.kingSpots = [2]*spot{}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			if k, ok := g.board.boxes[x][y].piece.(*king); ok {
+				if k.isWhite() {
+					g.kingSpots[0] = g.board.boxes[x][y]
+				} else {
+					g.kingSpots[1] = g.board.boxes[x][y]
 				}
 			}
 		}
 	}
-
-	return true
 }
 
-func (g *Game) isStalemate() bool {
-	if g.kingInCheck() {
-		return false
-	}
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			box := g.board.boxes[i][j]
-			if box.piece == nil {
-				continue
-			}
-			if box.piece.isWhite() != g.isWhiteTurn {
-				continue
-			}
-
-			for x := 0; x < 8; x++ {
-				for y := 0; y < 8; y++ {
-					if !box.piece.canMove(g.board, box, g.board.boxes[x][y]) {
-						continue
-					}
-
-					tmpPiece := g.board.boxes[x][y].piece
-					g.board.boxes[x][y].piece = box.piece
-					box.piece = nil
-
-					if !g.kingInCheck() {
-						box.piece = g.board.boxes[x][y].piece
-						g.board.boxes[x][y].piece = tmpPiece
-						return false
-					}
-
-					box.piece = g.board.boxes[x][y].piece
-					g.board.boxes[x][y].piece = tmpPiece
-				}
-			}
-		}
-	}
+func (g *Game) kingInCheck() bool {
+	return g.position().inCheck()
+}
 
-	return true
+func (g *Game) kingInCheckmate() bool {
+	return g.kingInCheck() && !g.hasLegalMove()
 }
 
 func (g *Game) updateBoard(move *move) {
+	if move.isDrop {
+		move.end.piece = move.pieceMoved
+		return
+	}
 	move.start.piece = nil
 	switch p := move.pieceMoved.(type) {
 	case *pawn:
@@ -330,9 +286,11 @@ func (g *Game) updateBoard(move *move) {
 		}
 		if move.isEnpassant {
 			move.end.piece = move.pieceMoved
-			g.board.boxes[move.end.x][move.start.y].piece = nil
+			move.enpassantSpot = g.board.boxes[move.end.x][move.start.y]
+			move.enpassantTaken = move.enpassantSpot.piece
+			move.enpassantSpot.piece = nil
 		} else if move.isPromoting {
-			move.end.piece = p.promote("queen")
+			move.end.piece = p.promote(move.promotion)
 			move.piecePromoted = move.end.piece
 		}
 	case *king:
@@ -340,35 +298,24 @@ func (g *Game) updateBoard(move *move) {
 			move.isInitMove = true
 			p.initMoved = true
 		}
-		if p.isWhite() {
-			if move.isCastling {
-				if move.end.x == 0 {
-					g.kingSpots[0] = g.board.boxes[2][0]
-					g.board.boxes[3][0].piece = move.pieceTaken
-				} else if move.end.x == 7 {
-					g.kingSpots[0] = g.board.boxes[6][0]
-					g.board.boxes[5][0].piece = move.pieceTaken
-				}
-				g.kingSpots[0].piece = move.pieceMoved
-				move.end.piece = nil
-			} else {
-				g.kingSpots[0] = move.end
+		side := 0
+		if !p.isWhite() {
+			side = 1
+		}
+		if move.isCastling {
+			// the king lands on the g or c file and the rook next to it, wherever they started
+			kingX, rookX := 6, 5
+			if move.end.x < move.start.x {
+				kingX, rookX = 2, 3
 			}
+			move.end.piece = nil
+			g.kingSpots[side] = g.board.boxes[kingX][move.end.y]
+			move.castlingRookSpot = g.board.boxes[rookX][move.end.y]
+			move.castlingRookSpot.piece = move.pieceTaken
+			g.kingSpots[side].piece = move.pieceMoved
 		} else {
-			if move.isCastling {
-				if move.end.x == 0 {
-					g.kingSpots[1] = g.board.boxes[2][7]
-					g.board.boxes[3][7].piece = move.pieceTaken
-				} else if move.end.x == 7 {
-					g.kingSpots[1] = g.board.boxes[6][7]
-					g.board.boxes[5][7].piece = move.pieceTaken
-				}
-				g.kingSpots[1].piece = move.pieceMoved
-				move.end.piece = nil
-			} else {
-				move.end.piece = move.pieceMoved
-				g.kingSpots[0] = move.end
-			}
+			move.end.piece = move.pieceMoved
+			g.kingSpots[side] = move.end
 		}
 	case *rook:
 		if !p.initMoved {
@@ -381,12 +328,73 @@ func (g *Game) updateBoard(move *move) {
 	}
 }
 
+/*
+Make a move from startPos to endPos for the player with the given id.
+A pawn reaching the last rank requires a promotion suffix on endPos,
+e.g. "e8n" promotes to a knight. In variants with pockets, a piece is
+dropped by giving its letter and "@" as startPos, e.g. "N@" and "f3"
+*/
 func (g *Game) MakeMove(playerId, startPos, endPos string) error {
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+
 	// check correct turn for move made by player with given id
 	if !g.correctTurn(playerId) {
 		return fmt.Errorf("wrong turn for player id: %s", playerId)
 	}
 
+	return g.makeMove(playerId, startPos, endPos)
+}
+
+// play the move for the side to move, whatever the game status
+func (g *Game) makeMove(playerId, startPos, endPos string) error {
+	var move *move
+	var err error
+	if kind, ok := parseDropStart(startPos); ok {
+		move, err = g.newDrop(playerId, kind, endPos)
+	} else {
+		move, err = g.newMove(playerId, startPos, endPos)
+	}
+	if err != nil {
+		return err
+	}
+
+	prevCastling, prevEnpassant, prevPockets := g.castlingMask(), g.enpassantFile(), g.pockets
+	snapshots := g.snapshotMoveSpots(move)
+	g.saveUndoState(move)
+
+	g.updateBoard(move)
+	g.updatePockets(move)
+	g.updateMoveCounters(move)
+
+	// add move to played moves history in the game
+	g.moves = append(g.moves, move)
+
+	g.checkAndNextTurn(move)
+	g.updateHash(snapshots, prevCastling, prevEnpassant, prevPockets)
+	g.updateRepetitions()
+	g.checkMoveRule()
+
+	return nil
+}
+
+// move of the piece on startPos, checked against the current position
+func (g *Game) newMove(playerId, startPos, endPos string) (*move, error) {
+	// split the promotion suffix from the destination square
+	promotion := ""
+	if len(endPos) == 3 {
+		name, ok := promotionPieces[endPos[2]]
+		if !ok {
+			return nil, fmt.Errorf("invalid promotion piece: %c", endPos[2])
+		}
+		promotion = name
+		endPos = endPos[:2]
+	}
+	if !isSquare(startPos) || !isSquare(endPos) {
+		return nil, fmt.Errorf("invalid move: %s-%s", startPos, endPos)
+	}
+
 	// map chess position to board coordinate
 	startX, startY := mapChessPosToCoord(startPos)
 	endX, endY := mapChessPosToCoord(endPos)
@@ -395,24 +403,19 @@ func (g *Game) MakeMove(playerId, startPos, endPos string) error {
 	startBox := g.board.boxes[startX][startY]
 	endBox := g.board.boxes[endX][endY]
 	move := &move{
-		playerId: playerId,
-		startPos: startPos,
-		endPos:   endPos,
-		start:    startBox,
-		end:      endBox,
+		playerId:  playerId,
+		startPos:  startPos,
+		endPos:    endPos,
+		start:     startBox,
+		end:       endBox,
+		promotion: promotion,
 	}
 
 	if err := g.checkMove(move); err != nil {
-		return err
+		return nil, err
 	}
-
-	g.updateBoard(move)
-	g.checkAndNextTurn(move)
-
-	// add move to played moves history in the game
-	g.moves = append(g.moves, move)
-
-	return nil
+	move.disambiguation = g.sanDisambiguation(move.start, move.end)
+	return move, nil
 }
 
 func (g *Game) checkMove(move *move) error {
@@ -427,38 +430,48 @@ func (g *Game) checkMove(move *move) error {
 		return errors.New("can't play your opponent's piece")
 	}
 
+	pos := g.position()
+	from, to := squareIndex(move.start.x, move.start.y), squareIndex(move.end.x, move.end.y)
+
 	// check valid move
-	switch p := srcPiece.(type) {
-	case *pawn:
-		if !p.canMove(g.board, move.start, move.end) {
-			if p.canEnpassant(move.start, move.end, g.GetLastMove()) {
-				move.isEnpassant = true
-			} else {
-				return fmt.Errorf("invalid pawn move: %s-%s", move.startPos, move.endPos)
-			}
+	if !pos.isPseudoLegal(from, to) {
+		switch srcPiece.(type) {
+		case *pawn:
+			return fmt.Errorf("invalid pawn move: %s-%s", move.startPos, move.endPos)
+		case *king:
+			return fmt.Errorf("invalid king move: %s-%s", move.startPos, move.endPos)
+		default:
+			return fmt.Errorf("invalid move: %s-%s", move.startPos, move.endPos)
 		}
+	}
+
+	switch srcPiece.(type) {
+	case *pawn:
+		// a diagonal move to an empty spot can only be en passant
+		move.isEnpassant = move.start.x != move.end.x && dstPiece == nil
 		if move.end.y == 7 || move.end.y == 0 {
 			move.isPromoting = true
+			if move.promotion == "" {
+				return fmt.Errorf("missing promotion piece: %s-%s", move.startPos, move.endPos)
+			}
 		}
 	case *king:
-		move.isCastling = isCastlingMove(move.start.x, move.start.y, move.end.x, move.end.y)
-		if !p.canMove(g.board, move.start, move.end) {
-			return fmt.Errorf("invalid king move: %s-%s", move.startPos, move.endPos)
-		}
-	default:
-		if !p.canMove(g.board, move.start, move.end) {
-			return fmt.Errorf("invalid move: %s-%s", move.startPos, move.endPos)
-		}
+		// the only king move onto its own piece is castling with the rook
+		move.isCastling = dstPiece != nil && dstPiece.isWhite() == srcPiece.isWhite()
+	}
+
+	if move.promotion != "" && !move.isPromoting {
+		return fmt.Errorf("invalid promotion: %s-%s", move.startPos, move.endPos)
 	}
 
-	if !move.isCastling {
-		move.end.piece = srcPiece
-		move.start.piece = nil
-		if g.kingInCheck() {
-			move.start.piece = srcPiece
-			move.end.piece = dstPiece
-			return fmt.Errorf("invalid move: %s-%s, king in checked", move.startPos, move.endPos)
+	if !pos.isLegal(from, to) {
+		if !g.variant.royalKing() {
+			return fmt.Errorf("invalid move: %s-%s", move.startPos, move.endPos)
 		}
+		return fmt.Errorf("invalid move: %s-%s, king in check", move.startPos, move.endPos)
+	}
+	if move.isPromoting && !pos.isLegalPromotion(from, to, promotionKindNames[move.promotion]) {
+		return fmt.Errorf("invalid promotion: %s-%s", move.startPos, move.endPos)
 	}
 
 	move.pieceMoved = srcPiece
@@ -470,6 +483,13 @@ func (g *Game) checkMove(move *move) error {
 	return nil
 }
 
+/*
+Check if the piece on start can move to end without leaving its own king in check
+*/
+func (g *Game) isLegalMove(start, end *spot) bool {
+	return g.position().isLegal(squareIndex(start.x, start.y), squareIndex(end.x, end.y))
+}
+
 func (g *Game) PrintBoard() {
 	fmt.Println("  +-----------------+")
 
@@ -490,6 +510,13 @@ func (g *Game) PrintBoard() {
 	fmt.Println()
 }
 
+func (g *Game) currentPlayerId() string {
+	if g.isWhiteTurn {
+		return g.playerIds[0]
+	}
+	return g.playerIds[1]
+}
+
 func (g *Game) correctTurn(playerId string) bool {
 	if g.isWhiteTurn {
 		return playerId == g.playerIds[0]
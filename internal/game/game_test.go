@@ -36,6 +36,8 @@ func setGame(testPiece string) (*Game, string, string) {
 			"a5-c7", "f7-f6", "c7-d7", "e8-f7", "d7-b7", "d8-d3", "b7-b8", "d3-h7",
 			"b8-c8", "f7-g6", "c8-e6",
 		}
+	case "promotion":
+		moves = []string{"h2-h4", "g7-g5", "h4-g5", "h7-h6", "g5-h6", "a7-a6", "h6-h7", "a6-a5"}
 	case "checkmate":
 		moves = []string{"e2-e4", "e7-e5", "f1-c4", "b8-c6", "d1-h5", "g8-f6", "h5-f7"}
 	default:
@@ -117,6 +119,27 @@ func TestKing(t *testing.T) {
 	igame.PrintBoard()
 }
 
+func TestPromotion(t *testing.T) {
+	igame, p1, _ := setGame("promotion")
+	if err := igame.MakeMove(p1, "h7", "g8"); err == nil {
+		t.Error("Test promotion: want error for missing promotion piece")
+	}
+	if err := igame.MakeMove(p1, "h7", "g8k"); err == nil {
+		t.Error("Test promotion: want error for illegal promotion piece")
+	}
+	if err := igame.MakeMove(p1, "h7", "g8n"); err != nil {
+		t.Error(err)
+	}
+	if _, ok := igame.board.boxes[6][7].piece.(*knight); !ok {
+		t.Errorf("Test promotion: got %T, want knight", igame.board.boxes[6][7].piece)
+	}
+	moves := igame.GetAllMoves()
+	if moves[len(moves)-1] != "h7-g8n" {
+		t.Errorf("Test promotion: got %s, want %s", moves[len(moves)-1], "h7-g8n")
+	}
+	igame.PrintBoard()
+}
+
 func TestStalemate(t *testing.T) {
 	igame, _, _ := setGame("stalemate")
 	if igame.GetStatus() != "STALEMATE" {
@@ -132,3 +155,509 @@ func TestCheckmate(t *testing.T) {
 	}
 	igame.PrintBoard()
 }
+
+func TestInitGameFromFEN(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		wantErr bool
+	}{
+		{"Starting position", StartingFEN, false},
+		{"Black to move with en passant", "rnbqkbnr/pppp1ppp/8/8/3Pp3/8/PPP1PPPP/RNBQKBNR b KQkq d3 0 3", false},
+		{"Missing fields", "8/8/8/8/8/8/8/8 w - -", true},
+		{"Missing king", "8/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"Pawn on back rank", "P3k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"Castling without rook", "4k3/8/8/8/8/8/8/4K3 w K - 0 1", true},
+		{"Bad en passant square", "4k3/8/8/8/8/8/8/4K3 w - e6 0 1", true},
+		{"Side not to move in check", "4k3/8/8/8/8/8/8/4R2K w - - 0 1", true},
+		{"Promoted mark after overflowing rank", "4k3/ppp17~/8/8/8/8/8/4K3 w - - 0 1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := InitGameFromFEN(generatePlayerIds(), tt.fen)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInitGameFromFENEnpassant(t *testing.T) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "rnbqkbnr/pppp1ppp/8/8/3Pp3/8/PPP1PPPP/RNBQKBNR b KQkq d3 0 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, p2 := igame.GetPlayerIds()
+	if err := igame.MakeMove(p2, "e4", "d3"); err != nil {
+		t.Error(err)
+	}
+	if igame.board.boxes[3][3].piece != nil {
+		t.Error("Test en passant from fen: captured pawn still on board")
+	}
+	igame.PrintBoard()
+}
+
+func TestInitGameFromFENCheckmate(t *testing.T) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "WHITE_CHECKMATE" {
+		t.Errorf("Test checkmate from fen: got %s, want %s", igame.GetStatus(), "WHITE_CHECKMATE")
+	}
+}
+
+func TestFEN(t *testing.T) {
+	igame := InitGame(generatePlayerIds())
+	if igame.FEN() != StartingFEN {
+		t.Errorf("Test fen: got %s, want %s", igame.FEN(), StartingFEN)
+	}
+
+	igame, _, _ = setGame("pawn")
+	want := "rnbqkbnr/pppp1ppp/8/4p3/3PP3/8/PPP2PPP/RNBQKBNR b KQkq d3 0 2"
+	if igame.FEN() != want {
+		t.Errorf("Test fen: got %s, want %s", igame.FEN(), want)
+	}
+
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"rnbqkbnr/pppp1ppp/8/8/3Pp3/8/PPP1PPPP/RNBQKBNR b Kq d3 0 3",
+	}
+	for _, fen := range fens {
+		igame, err := InitGameFromFEN(generatePlayerIds(), fen)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if igame.FEN() != fen {
+			t.Errorf("Test fen round trip: got %s, want %s", igame.FEN(), fen)
+		}
+	}
+}
+
+func playSAN(igame *Game, moves []string) error {
+	p1, p2 := igame.GetPlayerIds()
+	for _, san := range moves {
+		startPos, endPos, err := igame.ParseSAN(san)
+		if err != nil {
+			return err
+		}
+		playerId := p1
+		if !igame.GetCurrentTurn() {
+			playerId = p2
+		}
+		if err := igame.MakeMove(playerId, startPos, endPos); err != nil {
+			return fmt.Errorf("%s: %w", san, err)
+		}
+	}
+	return nil
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+	}{
+		{
+			"Ruy Lopez with castling",
+			StartingFEN,
+			[]string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7", "Re1", "b5", "Bb3", "d6", "c3", "O-O"},
+		},
+		{
+			"Scholar's mate",
+			StartingFEN,
+			[]string{"e4", "e5", "Bc4", "Nc6", "Qh5", "Nf6", "Qxf7#"},
+		},
+		{
+			"Disambiguation and promotion",
+			"4k3/1P6/8/8/8/8/8/1N2KN2 w - - 0 1",
+			[]string{"Nbd2", "Kd7", "b8=N+", "Kc8", "Ne3"},
+		},
+		{
+			"En passant",
+			"4k3/8/8/8/3p4/8/4P3/4K3 w - - 0 1",
+			[]string{"e4", "dxe3", "Kd1", "e2+"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			igame, err := InitGameFromFEN(generatePlayerIds(), tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := playSAN(igame, tt.moves); err != nil {
+				t.Fatal(err)
+			}
+			got := igame.GetAllMovesSAN()
+			if strings.Join(got, " ") != strings.Join(tt.moves, " ") {
+				t.Errorf("got %v, want %v", got, tt.moves)
+			}
+		})
+	}
+}
+
+func TestSANAmbiguous(t *testing.T) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := igame.ParseSAN("Nd2"); err == nil {
+		t.Error("Test san: want error for ambiguous move")
+	}
+	if _, _, err := igame.ParseSAN("Nc4"); err == nil {
+		t.Error("Test san: want error for illegal move")
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"e2-e4", []string{"e2", "e4"}, false},
+		{"e2e4", []string{"e2", "e4"}, false},
+		{"e7-e8n", []string{"e7", "e8n"}, false},
+		{"e7e8q", []string{"e7", "e8q"}, false},
+		{"e7e8k", []string{"e7", "e8k"}, false}, // antichess promotes to a king
+		{"e7e8p", nil, true},
+		{"e2-e9", nil, true},
+		{"e2", nil, true},
+		{"Nf3", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMove(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUCI(t *testing.T) {
+	igame, p1, _ := setGame("king")
+	startPos, endPos, err := igame.ResolveMove("e1g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if startPos != "e1" || endPos != "h1" {
+		t.Errorf("Test uci castling: got %s-%s, want %s", startPos, endPos, "e1-h1")
+	}
+	if err := igame.MakeMove(p1, startPos, endPos); err != nil {
+		t.Fatal(err)
+	}
+
+	moves := igame.GetAllMovesUCI()
+	if moves[len(moves)-1] != "e1g1" {
+		t.Errorf("Test uci castling: got %s, want %s", moves[len(moves)-1], "e1g1")
+	}
+	if moves[0] != "e2e4" {
+		t.Errorf("Test uci: got %s, want %s", moves[0], "e2e4")
+	}
+}
+
+func TestLegalMoves(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want int
+	}{
+		{"Starting position", StartingFEN, 20},
+		{"Kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 48},
+		{"Promotions", "n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1", 24},
+		{"Checkmated", "r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 0 4", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			igame, err := InitGameFromFEN(generatePlayerIds(), tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := igame.LegalMoves(); len(got) != tt.want {
+				t.Errorf("got %d moves, want %d: %v", len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLegalMovesFrom(t *testing.T) {
+	igame, _, _ := setGame("king")
+	got, err := igame.LegalMovesFrom("e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"e1-e2", "e1-f1", "e1-h1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Test legal moves from: got %v, want %v", got, want)
+	}
+	if _, err := igame.LegalMovesFrom("z9"); err == nil {
+		t.Error("Test legal moves from: want error for invalid square")
+	}
+}
+
+func TestHash(t *testing.T) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "r3k2r/1P6/8/8/3p4/8/4P3/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves := []string{"e4", "dxe3", "O-O", "e2", "bxa8=Q+", "Ke7", "Qxh8", "exf1=N"}
+	for _, san := range moves {
+		if err := playSAN(igame, []string{san}); err != nil {
+			t.Fatal(err)
+		}
+		if igame.hash != igame.computeHash() {
+			t.Errorf("Test hash after %s: incremental hash doesn't match", san)
+		}
+	}
+}
+
+func TestRepetition(t *testing.T) {
+	igame, p1, _ := setGame("")
+	shuffle := []string{"Nf3", "Nf6", "Ng1", "Ng8"}
+
+	if err := playSAN(igame, shuffle); err != nil {
+		t.Fatal(err)
+	}
+	if err := igame.ClaimThreefoldRepetition(p1); err == nil {
+		t.Error("Test repetition: want error for claim on second occurrence")
+	}
+
+	if err := playSAN(igame, shuffle); err != nil {
+		t.Fatal(err)
+	}
+	if igame.RepetitionCount() != 3 {
+		t.Errorf("Test repetition: got %d, want %d", igame.RepetitionCount(), 3)
+	}
+
+	igame2, _, _ := setGame("")
+	if err := playSAN(igame2, append(append(shuffle, shuffle...), append(shuffle, shuffle...)...)); err != nil {
+		t.Fatal(err)
+	}
+	if igame2.GetStatus() != "FIVEFOLD_REPETITION" {
+		t.Errorf("Test repetition: got %s, want %s", igame2.GetStatus(), "FIVEFOLD_REPETITION")
+	}
+
+	if err := igame.ClaimThreefoldRepetition(p1); err != nil {
+		t.Error(err)
+	}
+	if igame.GetStatus() != "THREEFOLD_REPETITION" {
+		t.Errorf("Test repetition: got %s, want %s", igame.GetStatus(), "THREEFOLD_REPETITION")
+	}
+}
+
+func TestMoveRule(t *testing.T) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/8/R3K3 w - - 98 80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, _ := igame.GetPlayerIds()
+	if err := igame.ClaimFiftyMoveRule(p1); err == nil {
+		t.Error("Test fifty-move rule: want error for early claim")
+	}
+	if err := playSAN(igame, []string{"Ra2", "Kd8"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(igame.FEN(), " 100 81") {
+		t.Errorf("Test fifty-move rule: got %s, want counters %s", igame.FEN(), "100 81")
+	}
+	if err := igame.ClaimFiftyMoveRule(p1); err != nil {
+		t.Error(err)
+	}
+	if igame.GetStatus() != "FIFTY_MOVE_RULE" {
+		t.Errorf("Test fifty-move rule: got %s, want %s", igame.GetStatus(), "FIFTY_MOVE_RULE")
+	}
+
+	igame, err = InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/8/R3K3 w - - 148 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"Ra2"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "ACTIVE" {
+		t.Errorf("Test seventy-five-move rule: got %s, want %s", igame.GetStatus(), "ACTIVE")
+	}
+	if err := playSAN(igame, []string{"Kd8"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "SEVENTY_FIVE_MOVE_RULE" {
+		t.Errorf("Test seventy-five-move rule: got %s, want %s", igame.GetStatus(), "SEVENTY_FIVE_MOVE_RULE")
+	}
+
+	// a position loaded past the limit is drawn at once, unless it's checkmate
+	igame, err = InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/8/R3K3 w - - 150 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "SEVENTY_FIVE_MOVE_RULE" {
+		t.Errorf("Test seventy-five-move rule: got %s loading a position, want %s", igame.GetStatus(), "SEVENTY_FIVE_MOVE_RULE")
+	}
+	igame, err = InitGameFromFEN(generatePlayerIds(), "R3k3/8/4K3/8/8/8/8/8 b - - 150 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "WHITE_CHECKMATE" {
+		t.Errorf("Test seventy-five-move rule: got %s loading a checkmate, want %s", igame.GetStatus(), "WHITE_CHECKMATE")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	igame, p1, p2 := setGame("")
+	if err := igame.ClaimDraw(p1); err == nil {
+		t.Error("Test draw: want error for claim without repetition or fifty moves")
+	}
+	if err := playSAN(igame, []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := igame.ClaimDraw(p2); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "THREEFOLD_REPETITION" {
+		t.Errorf("Test draw: got %s, want %s", igame.GetStatus(), "THREEFOLD_REPETITION")
+	}
+
+	fifty, err := InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/8/R3K3 w - - 100 80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, _ = fifty.GetPlayerIds()
+	if err := fifty.ClaimDraw(p1); err != nil {
+		t.Fatal(err)
+	}
+	if fifty.GetStatus() != "FIFTY_MOVE_RULE" {
+		t.Errorf("Test draw: got %s, want %s", fifty.GetStatus(), "FIFTY_MOVE_RULE")
+	}
+
+	agreed := InitGame(generatePlayerIds())
+	if err := agreed.AgreeDraw(); err != nil {
+		t.Fatal(err)
+	}
+	if agreed.GetStatus() != "DRAW_AGREEMENT" || agreed.Result() != "1/2-1/2" {
+		t.Errorf("Test draw: got status %s, result %s", agreed.GetStatus(), agreed.Result())
+	}
+	if err := agreed.AgreeDraw(); err == nil {
+		t.Error("Test draw: want error for game already over")
+	}
+}
+
+func TestResign(t *testing.T) {
+	igame := InitGame(generatePlayerIds())
+	p1, p2 := igame.GetPlayerIds()
+	if err := igame.Resign("nobody"); err == nil {
+		t.Error("Test resign: want error for player not in the game")
+	}
+
+	// black may resign on white's turn
+	if err := igame.Resign(p2); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "BLACK_RESIGN" || igame.Result() != "1-0" {
+		t.Errorf("Test resign: got status %s, result %s", igame.GetStatus(), igame.Result())
+	}
+	if err := igame.Resign(p1); err == nil {
+		t.Error("Test resign: want error for game already over")
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want string
+	}{
+		{"King vs king", "4k3/8/8/8/8/8/3p4/4K3 w - - 0 1", "Kxd2", "INSUFFICIENT_MATERIAL"},
+		{"King and knight vs king", "4k3/8/8/8/8/8/3p4/4K1N1 w - - 0 1", "Kxd2", "INSUFFICIENT_MATERIAL"},
+		{"King and bishop vs king", "4k3/8/8/8/8/8/3p4/4KB2 w - - 0 1", "Kxd2", "INSUFFICIENT_MATERIAL"},
+		{"Same coloured bishops", "4kb2/8/8/8/8/8/3p4/4K1B1 w - - 0 1", "Kxd2", "INSUFFICIENT_MATERIAL"},
+		{"Opposite coloured bishops", "4k1b1/8/8/8/8/8/3p4/4K1B1 w - - 0 1", "Kxd2", "ACTIVE"},
+		{"Knight vs knight", "4kn2/8/8/8/8/8/3p4/4K1N1 w - - 0 1", "Kxd2", "ACTIVE"},
+		{"Rook remains", "4k3/8/8/8/8/8/3p4/R3K3 w - - 0 1", "Kxd2", "ACTIVE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			igame, err := InitGameFromFEN(generatePlayerIds(), tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := playSAN(igame, []string{tt.move}); err != nil {
+				t.Fatal(err)
+			}
+			if igame.GetStatus() != tt.want {
+				t.Errorf("got %s, want %s", igame.GetStatus(), tt.want)
+			}
+		})
+	}
+
+	igame, err := InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/3p4/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !igame.position().insufficientMaterialFor(true) || igame.position().insufficientMaterialFor(false) {
+		t.Error("Test insufficient material for: lone king can't mate, a pawn can")
+	}
+}
+
+func TestUndoMove(t *testing.T) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "r3k2r/1P6/8/8/3p4/8/4P3/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves := []string{"e4", "dxe3", "O-O", "e2", "bxa8=Q+", "Ke7", "Qxh8", "exf1=N"}
+	fens := []string{igame.FEN()}
+	hashes := []uint64{igame.hash}
+	for _, san := range moves {
+		if err := playSAN(igame, []string{san}); err != nil {
+			t.Fatal(err)
+		}
+		fens = append(fens, igame.FEN())
+		hashes = append(hashes, igame.hash)
+	}
+
+	for i := len(moves) - 1; i >= 0; i-- {
+		if err := igame.UndoMove(); err != nil {
+			t.Fatal(err)
+		}
+		if igame.FEN() != fens[i] {
+			t.Errorf("Test undo %s: got %s, want %s", moves[i], igame.FEN(), fens[i])
+		}
+		if igame.hash != hashes[i] || igame.hash != igame.computeHash() {
+			t.Errorf("Test undo %s: hash not restored", moves[i])
+		}
+	}
+	if err := igame.UndoMove(); err == nil {
+		t.Error("Test undo: want error with no moves played")
+	}
+
+	// replay after undo
+	if err := playSAN(igame, moves); err != nil {
+		t.Fatal(err)
+	}
+	if igame.FEN() != fens[len(fens)-1] {
+		t.Errorf("Test undo replay: got %s, want %s", igame.FEN(), fens[len(fens)-1])
+	}
+}
+
+func TestUndoCheckmate(t *testing.T) {
+	igame, _, _ := setGame("checkmate")
+	if err := igame.UndoMove(); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "ACTIVE" {
+		t.Errorf("Test undo checkmate: got %s, want %s", igame.GetStatus(), "ACTIVE")
+	}
+	if len(igame.LegalMoves()) == 0 {
+		t.Error("Test undo checkmate: no legal moves after undo")
+	}
+}
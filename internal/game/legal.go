@@ -0,0 +1,56 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+Return every legal move for the side to move in the form of "e2-e4".
+Promotions are listed once per promotion piece (e.g. "e7-e8q", "e7-e8n"),
+castling is listed as the king-to-rook move (e.g. "e1-h1") and drops
+are listed as "N@f3"
+*/
+func (g *Game) LegalMoves() []string {
+	moves := []string{}
+	if g.IsOver() {
+		return moves
+	}
+	for _, m := range g.position().legalMoves() {
+		moves = append(moves, m.String())
+	}
+	sort.Strings(moves)
+	return moves
+}
+
+/*
+Return the legal moves of the piece on the given square, e.g. "e2"
+*/
+func (g *Game) LegalMovesFrom(square string) ([]string, error) {
+	if !isSquare(square) {
+		return nil, fmt.Errorf("invalid square: %s", square)
+	}
+	if g.IsOver() {
+		return []string{}, nil
+	}
+	x, y := mapChessPosToCoord(square)
+	moves := g.formatLegalMovesFrom(g.board.boxes[x][y])
+	sort.Strings(moves)
+	return moves, nil
+}
+
+// legal moves of the piece on start, one per promotion piece the variant allows
+func (g *Game) formatLegalMovesFrom(start *spot) []string {
+	moves := []string{}
+	from := squareIndex(start.x, start.y)
+	for _, m := range g.position().legalMoves() {
+		if !m.drop && int(m.from) == from {
+			moves = append(moves, m.String())
+		}
+	}
+	return moves
+}
+
+func (g *Game) hasLegalMove() bool {
+	return len(g.position().legalMoves()) > 0
+}
@@ -3,24 +3,41 @@ package game
 import (
 	"errors"
 	"strings"
+	"time"
 )
 
 type MoveStatus string
 
 type move struct {
-	playerId      string
-	startPos      string
-	endPos        string
-	start         *spot
-	end           *spot
-	pieceMoved    piece
-	pieceTaken    piece
-	piecePromoted piece
-	isCastling    bool
-	isChecking    bool
-	isEnpassant   bool
-	isPromoting   bool
-	isInitMove    bool
+	playerId       string
+	startPos       string
+	endPos         string
+	start          *spot
+	end            *spot
+	pieceMoved     piece
+	pieceTaken     piece
+	piecePromoted  piece
+	isCastling     bool
+	isChecking     bool
+	isEnpassant    bool
+	isPromoting    bool
+	isInitMove     bool
+	isDrop         bool   // piece put on end from the pocket, start is nil
+	promotion      string // name of the piece requested for promotion
+	disambiguation string // origin file, rank or square written in SAN
+	hasClock       bool
+	clock          time.Duration // time left to the mover after the move
+
+	// state needed to take the move back
+	enpassantSpot      *spot // spot of the pawn taken en passant
+	enpassantTaken     piece
+	castlingRookSpot   *spot // spot the rook lands on when castling
+	prevKingSpots      [2]*spot
+	prevStatus         GameStatus
+	prevHalfmoveClock  int
+	prevFullmoveNumber int
+	prevHash           uint64
+	prevPockets        [2][kindKing]int
 }
 
 func mapChessPosToCoord(pos string) (x int, y int) {
@@ -29,45 +46,85 @@ func mapChessPosToCoord(pos string) (x int, y int) {
 	return
 }
 
+func mapCoordToChessPos(x, y int) string {
+	return string([]byte{byte('a' + x), byte('1' + y)})
+}
+
+// promotion suffixes accepted after the destination square and the pieces they stand for
+var promotionPieces = map[byte]string{
+	'q': "queen",
+	'r': "rook",
+	'b': "bishop",
+	'n': "knight",
+	'k': "king", // in variants where the king isn't royal
+}
+
+func isSquare(pos string) bool {
+	return len(pos) == 2 &&
+		pos[0] >= 'a' && pos[0] <= 'h' &&
+		pos[1] >= '1' && pos[1] <= '8'
+}
+
+/*
+Parse a move in the form of "e2-e4" or in UCI long algebraic notation "e2e4".
+A promotion piece can be appended to the destination square, e.g. "e7-e8n"
+or "e7e8n". The result holds the start square and the destination square
+with its promotion suffix if there is one. A drop is given as "N@f3" and
+parsed into "N@" and "f3"
+*/
 func ParseMove(move string) ([]string, error) {
 	move = strings.TrimSpace(move)
-	if len(move) != 5 {
-		return []string{}, errors.New("couldn't parse move")
+	if pos, ok := parseDrop(move); ok {
+		return pos, nil
 	}
+	move = strings.ToLower(move)
 
-	if move[2] != '-' {
+	if len(move) < 4 {
 		return []string{}, errors.New("couldn't parse move")
 	}
 
-	if move[0] >= 'a' && move[0] <= 'h' &&
-		move[1] >= '1' && move[1] <= '8' &&
-		move[3] >= 'a' && move[3] <= 'h' &&
-		move[4] >= '1' && move[4] <= '8' {
-		pos := strings.Split(move, "-")
-		return pos, nil
+	var pos []string
+	if move[2] == '-' {
+		pos = []string{move[:2], move[3:]}
 	} else {
+		pos = []string{move[:2], move[2:]}
+	}
+	if len(pos[1]) != 2 && len(pos[1]) != 3 {
 		return []string{}, errors.New("couldn't parse move")
 	}
-}
-func IsValidMove(move string) bool {
-	move = strings.TrimSpace(move)
-	if len(move) != 5 {
-		return false
+
+	if !isSquare(pos[0]) || !isSquare(pos[1][:2]) {
+		return []string{}, errors.New("couldn't parse move")
+	}
+	if len(pos[1]) == 3 {
+		if _, ok := promotionPieces[pos[1][2]]; !ok {
+			return []string{}, errors.New("couldn't parse promotion piece")
+		}
 	}
 
-	if move[2] != '-' {
-		return false
+	return pos, nil
+}
+
+/*
+Resolve a move given as "e2-e4", in UCI notation or in Standard Algebraic Notation
+against the current position. Castling given as the king's destination
+(e.g. "e1g1") is mapped onto the king-to-rook move used by MakeMove
+*/
+func (g *Game) ResolveMove(move string) (startPos, endPos string, err error) {
+	if pos, parseErr := ParseMove(move); parseErr == nil {
+		return pos[0], g.normalizeCastling(pos[0], pos[1]), nil
 	}
+	return g.ParseSAN(move)
+}
 
-	return move[0] >= 'a' && move[0] <= 'h' &&
-		move[1] >= '1' && move[1] <= '8' &&
-		move[3] >= 'a' && move[3] <= 'h' &&
-		move[4] >= '1' && move[3] <= '8'
+func IsValidMove(move string) bool {
+	_, err := ParseMove(move)
+	return err == nil
 }
 
 func (g *Game) GetLastMove() *move {
 	if len(g.moves) == 0 {
-		return nil
+		return g.fenLastMove
 	}
 	lastMove := g.moves[len(g.moves)-1]
 	return lastMove
@@ -76,7 +133,28 @@ func (g *Game) GetLastMove() *move {
 func (g *Game) GetAllMoves() []string {
 	res := make([]string, 0, len(g.moves))
 	for _, move := range g.moves {
-		res = append(res, move.startPos+"-"+move.endPos)
+		if move.isDrop {
+			res = append(res, move.startPos+move.endPos)
+			continue
+		}
+		res = append(res, move.startPos+"-"+move.endPos+promotionSuffix(move.piecePromoted))
 	}
 	return res
 }
+
+func promotionSuffix(p piece) string {
+	switch p.(type) {
+	case *queen:
+		return "q"
+	case *rook:
+		return "r"
+	case *bishop:
+		return "b"
+	case *knight:
+		return "n"
+	case *king:
+		return "k"
+	default:
+		return ""
+	}
+}
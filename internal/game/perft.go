@@ -0,0 +1,100 @@
+package game
+
+import "fmt"
+
+// promotion suffix of each piece kind
+var kindSuffixes = map[pieceKind]string{
+	kindQueen:  "q",
+	kindRook:   "r",
+	kindBishop: "b",
+	kindKnight: "n",
+	kindKing:   "k",
+}
+
+// the move in the form of "e2-e4", or "N@f3" for a drop
+func (m posMove) String() string {
+	startPos, endPos := m.positions()
+	if m.drop {
+		return startPos + endPos
+	}
+	return startPos + "-" + endPos
+}
+
+// start square and destination square with promotion suffix, as taken by MakeMove
+func (m posMove) positions() (string, string) {
+	from, to := int(m.from), int(m.to)
+	if m.drop {
+		return dropStart(m.dropped), mapCoordToChessPos(to%8, to/8)
+	}
+	return mapCoordToChessPos(from%8, from/8), mapCoordToChessPos(to%8, to/8) + kindSuffixes[m.promotion]
+}
+
+/*
+Count the leaf nodes of the move tree from the current position down to the given depth,
+the position itself being the only node at depth 0. Draw rules are ignored, only checkmate
+and stalemate end a line. The moves are played through MakeMove and UndoMove, so the game
+is left as it was
+*/
+func (g *Game) Perft(depth int) (int, error) {
+	if depth == 0 {
+		return 1, nil
+	}
+	divide, err := g.PerftDivide(depth)
+	if err != nil {
+		return 0, err
+	}
+	nodes := 0
+	for _, n := range divide {
+		nodes += n
+	}
+	return nodes, nil
+}
+
+/*
+Return the perft node count below each legal move of the current position,
+keyed by move in the form of "e2-e4", or "N@f3" for a drop. No move is counted at depth 0
+*/
+func (g *Game) PerftDivide(depth int) (map[string]int, error) {
+	if depth < 0 {
+		return nil, fmt.Errorf("invalid perft depth: %d", depth)
+	}
+	divide := map[string]int{}
+	if depth == 0 {
+		return divide, nil
+	}
+	for _, m := range g.position().legalMoves() {
+		nodes, err := g.perft(m, depth-1)
+		if err != nil {
+			return nil, err
+		}
+		divide[m.String()] = nodes
+	}
+	return divide, nil
+}
+
+func (g *Game) perft(m posMove, depth int) (int, error) {
+	if depth == 0 {
+		return 1, nil
+	}
+
+	startPos, endPos := m.positions()
+	// a move generated as legal must be accepted by the game
+	if err := g.makeMove(g.currentPlayerId(), startPos, endPos); err != nil {
+		return 0, fmt.Errorf("perft: %s-%s rejected: %w", startPos, endPos, err)
+	}
+	defer g.UndoMove()
+
+	moves := g.position().legalMoves()
+	if depth == 1 {
+		return len(moves), nil
+	}
+	nodes := 0
+	for _, next := range moves {
+		n, err := g.perft(next, depth-1)
+		if err != nil {
+			return 0, err
+		}
+		nodes += n
+	}
+	return nodes, nil
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"sort"
+	"testing"
+)
+
+// node counts from https://www.chessprogramming.org/Perft_Results
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes []int // by depth, starting at 1
+}{
+	{"Initial", StartingFEN, []int{20, 400, 8902, 197281}},
+	{"Kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []int{48, 2039, 97862}},
+	{"Position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []int{14, 191, 2812, 43238}},
+	{"Position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []int{6, 264, 9467}},
+	{"Position 4 mirrored", "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1", []int{6, 264, 9467}},
+	{"Position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []int{44, 1486, 62379}},
+	{"Position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", []int{46, 2079, 89890}},
+	// https://www.chessprogramming.org/Chess960_Perft_Results
+	{"Chess960 1", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", []int{21, 528, 12189}},
+	{"Chess960 2", "2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", []int{21, 807, 18002}},
+	{"Chess960 3", "b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", []int{20, 479, 10471}},
+	{"Chess960 4", "qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9", []int{22, 593, 13440}},
+	{"Chess960 5", "1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9", []int{28, 1120, 31058}},
+}
+
+func TestPerft(t *testing.T) {
+	for _, tt := range perftPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			igame, err := InitGameFromFEN(generatePlayerIds(), tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.nodes {
+				depth := i + 1
+				if testing.Short() && want > 10000 {
+					break
+				}
+				divide, err := igame.PerftDivide(depth)
+				if err != nil {
+					t.Fatalf("depth %d: %s", depth, err)
+				}
+				got := 0
+				for _, n := range divide {
+					got += n
+				}
+				if got != want {
+					t.Errorf("depth %d: got %d nodes, want %d", depth, got, want)
+					logDivide(t, divide)
+				}
+			}
+			if fen := igame.FEN(); fen != tt.fen {
+				t.Errorf("game not restored: got %s, want %s", fen, tt.fen)
+			}
+		})
+	}
+}
+
+func TestPerftDepth(t *testing.T) {
+	igame := InitGame(generatePlayerIds())
+	if nodes, err := igame.Perft(0); err != nil || nodes != 1 {
+		t.Errorf("Test perft depth: got %d nodes, error %v at depth 0, want 1", nodes, err)
+	}
+	if divide, err := igame.PerftDivide(0); err != nil || len(divide) != 0 {
+		t.Errorf("Test perft depth: got divide %v, error %v at depth 0", divide, err)
+	}
+	if _, err := igame.Perft(-1); err == nil {
+		t.Error("Test perft depth: want error for negative depth")
+	}
+}
+
+func logDivide(t *testing.T, divide map[string]int) {
+	moves := make([]string, 0, len(divide))
+	for move := range divide {
+		moves = append(moves, move)
+	}
+	sort.Strings(moves)
+	for _, move := range moves {
+		t.Logf("%s: %d", move, divide[move])
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	igame := InitGame(generatePlayerIds())
+	for range b.N {
+		if _, err := igame.Perft(3); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Tags of the PGN seven-tag roster given by the caller.
+The Result tag is taken from the game status
+*/
+type PGNTags struct {
+	Event string
+	Site  string
+	Date  string // in the form of "2024.06.28"
+	Round string
+	White string
+	Black string
+}
+
+// PGN game result of each status
+var statusResults = map[GameStatus]string{
+	active:               "*",
+	whiteCheckmate:       "1-0",
+	blackCheckmate:       "0-1",
+	whiteResign:          "0-1",
+	blackResign:          "1-0",
+	stalemate:            "1/2-1/2",
+	threefoldRepetition:  "1/2-1/2",
+	fivefoldRepetition:   "1/2-1/2",
+	fiftyMoveRule:        "1/2-1/2",
+	seventyFiveMoveRule:  "1/2-1/2",
+	insufficientMaterial: "1/2-1/2",
+	drawAgreement:        "1/2-1/2",
+	whiteTimeout:         "0-1",
+	blackTimeout:         "1-0",
+
+	timeoutVsInsufficientMaterial: "1/2-1/2",
+
+	whiteWins: "1-0",
+	blackWins: "0-1",
+	draw:      "1/2-1/2",
+}
+
+// reason the game ended, written as the last comment of the movetext
+var statusReasons = map[GameStatus]string{
+	whiteCheckmate:       "White wins by checkmate.",
+	blackCheckmate:       "Black wins by checkmate.",
+	whiteResign:          "White resigns.",
+	blackResign:          "Black resigns.",
+	stalemate:            "Draw by stalemate.",
+	threefoldRepetition:  "Draw by threefold repetition.",
+	fivefoldRepetition:   "Draw by fivefold repetition.",
+	fiftyMoveRule:        "Draw by the fifty-move rule.",
+	seventyFiveMoveRule:  "Draw by the seventy-five-move rule.",
+	insufficientMaterial: "Draw by insufficient material.",
+	drawAgreement:        "Draw by agreement.",
+	whiteTimeout:         "Black wins on time.",
+	blackTimeout:         "White wins on time.",
+
+	timeoutVsInsufficientMaterial: "Draw by timeout vs insufficient material.",
+
+	whiteWins: "White wins.",
+	blackWins: "Black wins.",
+	draw:      "Draw.",
+}
+
+// longest movetext line of the PGN export format
+const pgnLineLength = 80
+
+/*
+Return the game result as written in PGN: "1-0", "0-1", "1/2-1/2" or "*" while the game goes on
+*/
+func (g *Game) Result() string {
+	result, _ := g.statusResult(g.status)
+	return result
+}
+
+/*
+Export the game in Portable Game Notation with the seven-tag roster,
+the termination of the game and the moves in Standard Algebraic Notation
+*/
+func (g *Game) PGN(tags PGNTags) string {
+	var pgn strings.Builder
+
+	writePGNTag(&pgn, "Event", tags.Event)
+	writePGNTag(&pgn, "Site", tags.Site)
+	writePGNTag(&pgn, "Date", tags.Date)
+	writePGNTag(&pgn, "Round", tags.Round)
+	writePGNTag(&pgn, "White", tags.White)
+	writePGNTag(&pgn, "Black", tags.Black)
+	writePGNTag(&pgn, "Result", g.Result())
+	if g.variant != Standard {
+		writePGNTag(&pgn, "Variant", g.variant.Title())
+	} else if g.chess960 {
+		writePGNTag(&pgn, "Variant", Chess960.Title())
+	}
+	if g.startFEN != "" && g.startFEN != StartingFEN {
+		writePGNTag(&pgn, "SetUp", "1")
+		writePGNTag(&pgn, "FEN", g.startFEN)
+	}
+	writePGNTag(&pgn, "Termination", g.termination())
+	pgn.WriteString("\n")
+
+	tokens := g.movetextTokens()
+	if reason, ok := g.statusReason(g.status); ok {
+		tokens = append(tokens, "{"+reason+"}")
+	}
+	tokens = append(tokens, g.Result())
+
+	lineLength := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if lineLength+1+len(token) > pgnLineLength {
+				pgn.WriteString("\n")
+				lineLength = 0
+			} else {
+				pgn.WriteString(" ")
+				lineLength++
+			}
+		}
+		pgn.WriteString(token)
+		lineLength += len(token)
+	}
+	pgn.WriteString("\n")
+
+	return pgn.String()
+}
+
+// value of the Termination tag, games lost or drawn on time end by time forfeit
+func (g *Game) termination() string {
+	switch g.status {
+	case active:
+		return "unterminated"
+	case whiteTimeout, blackTimeout, timeoutVsInsufficientMaterial:
+		return "time forfeit"
+	}
+	return "normal"
+}
+
+func writePGNTag(pgn *strings.Builder, name, value string) {
+	if value == "" {
+		value = "?"
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(pgn, "[%s \"%s\"]\n", name, value)
+}
+
+// move numbers and moves in Standard Algebraic Notation
+func (g *Game) movetextTokens() []string {
+	moveNumber, isWhiteTurn := 1, true
+	if fields := strings.Fields(g.startFEN); len(fields) == 6 {
+		isWhiteTurn = fields[1] == "w"
+		if n, err := strconv.Atoi(fields[5]); err == nil {
+			moveNumber = n
+		}
+	}
+
+	tokens := []string{}
+	commented := false
+	for i, san := range g.GetAllMovesSAN() {
+		if isWhiteTurn {
+			tokens = append(tokens, strconv.Itoa(moveNumber)+".")
+		} else if i == 0 || commented {
+			// black's move number is repeated after a comment
+			tokens = append(tokens, strconv.Itoa(moveNumber)+"...")
+		}
+		tokens = append(tokens, san)
+		commented = g.moves[i].hasClock
+		if commented {
+			tokens = append(tokens, clockComment(g.moves[i].clock))
+		}
+		if !isWhiteTurn {
+			moveNumber++
+		}
+		isWhiteTurn = !isWhiteTurn
+	}
+	return tokens
+}
@@ -0,0 +1,374 @@
+package game
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+Game read from a PGN file. Only the mainline is replayed,
+variations are kept as written
+*/
+type PGNGame struct {
+	Tags     map[string]string
+	Comments []string // comments before the first move
+	Moves    []PGNMove
+	Result   string // game termination marker, "*" if there is none
+}
+
+type PGNMove struct {
+	SAN        string
+	NAGs       []int
+	Comments   []string
+	Variations []string // alternatives to this move as written in the file
+}
+
+// numeric annotation glyphs of the move suffix annotations
+var suffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+var (
+	pgnMoveNumber = regexp.MustCompile(`^\d+(\.+|$)`)
+	pgnResults    = map[string]bool{"1-0": true, "0-1": true, "1/2-1/2": true, "*": true}
+)
+
+type pgnParser struct {
+	text  string
+	pos   int
+	line  int
+	games []PGNGame
+	game  *PGNGame
+}
+
+/*
+Parse every game of a PGN file: tag pairs, movetext with comments,
+numeric annotation glyphs and variations
+*/
+func ParsePGN(text string) ([]PGNGame, error) {
+	p := &pgnParser{text: text, line: 1}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.games, nil
+}
+
+func (p *pgnParser) parse() error {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			break
+		}
+
+		switch c := p.text[p.pos]; c {
+		case '[':
+			// a tag section after movetext starts the next game
+			if p.game != nil && (len(p.game.Moves) > 0 || len(p.game.Comments) > 0) {
+				p.endGame("*")
+			}
+			if err := p.parseTag(); err != nil {
+				return err
+			}
+		case '{':
+			comment, err := p.readUntil('}')
+			if err != nil {
+				return err
+			}
+			p.addComment(strings.TrimSpace(comment))
+		case ';':
+			comment, _ := p.readUntil('\n')
+			p.addComment(strings.TrimSpace(comment))
+		case '(':
+			variation, err := p.readVariation()
+			if err != nil {
+				return err
+			}
+			if err := p.addVariation(variation); err != nil {
+				return err
+			}
+		case '$':
+			p.pos++
+			nag, err := strconv.Atoi(p.readToken())
+			if err != nil {
+				return p.errorf("invalid numeric annotation glyph")
+			}
+			if err := p.addNAG(nag); err != nil {
+				return err
+			}
+		case ')', ']', '}':
+			return p.errorf("unexpected %q", c)
+		default:
+			if err := p.parseMoveToken(p.readToken()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.game != nil {
+		p.endGame("*")
+	}
+	return nil
+}
+
+func (p *pgnParser) current() *PGNGame {
+	if p.game == nil {
+		p.game = &PGNGame{Tags: map[string]string{}}
+	}
+	return p.game
+}
+
+func (p *pgnParser) endGame(result string) {
+	g := p.current()
+	g.Result = result
+	p.games = append(p.games, *g)
+	p.game = nil
+}
+
+func (p *pgnParser) lastMove() *PGNMove {
+	g := p.current()
+	if len(g.Moves) == 0 {
+		return nil
+	}
+	return &g.Moves[len(g.Moves)-1]
+}
+
+func (p *pgnParser) addComment(comment string) {
+	if m := p.lastMove(); m != nil {
+		m.Comments = append(m.Comments, comment)
+	} else {
+		p.current().Comments = append(p.current().Comments, comment)
+	}
+}
+
+func (p *pgnParser) addVariation(variation string) error {
+	m := p.lastMove()
+	if m == nil {
+		return p.errorf("variation before the first move")
+	}
+	m.Variations = append(m.Variations, variation)
+	return nil
+}
+
+func (p *pgnParser) addNAG(nag int) error {
+	m := p.lastMove()
+	if m == nil {
+		return p.errorf("annotation glyph before the first move")
+	}
+	m.NAGs = append(m.NAGs, nag)
+	return nil
+}
+
+func (p *pgnParser) parseMoveToken(token string) error {
+	if token == "" {
+		return p.errorf("unexpected %q", p.text[p.pos])
+	}
+	if pgnResults[token] {
+		p.endGame(token)
+		return nil
+	}
+
+	// move numbers may be glued to the move, e.g. "12.e4"
+	token = pgnMoveNumber.ReplaceAllString(token, "")
+	if token == "" {
+		return nil
+	}
+
+	san := strings.TrimRight(token, "!?")
+	if san == "" {
+		return p.errorf("invalid move %q", token)
+	}
+	move := PGNMove{SAN: san}
+	if suffix := token[len(san):]; suffix != "" {
+		nag, ok := suffixNAGs[suffix]
+		if !ok {
+			return p.errorf("invalid move annotation %q", suffix)
+		}
+		move.NAGs = append(move.NAGs, nag)
+	}
+	p.current().Moves = append(p.current().Moves, move)
+	return nil
+}
+
+func (p *pgnParser) parseTag() error {
+	line := p.line
+	p.pos++
+	p.skipSpace()
+	name := p.readToken()
+	if name == "" {
+		return fmt.Errorf("invalid pgn: line %d: missing tag name", line)
+	}
+	p.skipSpace()
+	if p.pos >= len(p.text) || p.text[p.pos] != '"' {
+		return fmt.Errorf("invalid pgn: line %d: missing value of tag %s", line, name)
+	}
+	p.pos++
+
+	var value strings.Builder
+	for {
+		if p.pos >= len(p.text) || p.text[p.pos] == '\n' {
+			return fmt.Errorf("invalid pgn: line %d: unterminated value of tag %s", line, name)
+		}
+		c := p.text[p.pos]
+		p.pos++
+		if c == '"' {
+			break
+		}
+		if c == '\\' && p.pos < len(p.text) {
+			c = p.text[p.pos]
+			p.pos++
+		}
+		value.WriteByte(c)
+	}
+
+	p.skipSpace()
+	if p.pos >= len(p.text) || p.text[p.pos] != ']' {
+		return fmt.Errorf("invalid pgn: line %d: unterminated tag %s", line, name)
+	}
+	p.pos++
+
+	p.current().Tags[name] = value.String()
+	return nil
+}
+
+// text of a variation without its parentheses, nested variations and comments included
+func (p *pgnParser) readVariation() (string, error) {
+	line := p.line
+	start := p.pos + 1
+	depth := 0
+	for p.pos < len(p.text) {
+		switch p.text[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return strings.TrimSpace(p.text[start : p.pos-1]), nil
+			}
+		case '{':
+			if _, err := p.readUntil('}'); err != nil {
+				return "", err
+			}
+			continue
+		case '\n':
+			p.line++
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("invalid pgn: line %d: unterminated variation", line)
+}
+
+// text up to the delimiter, which is consumed
+func (p *pgnParser) readUntil(delim byte) (string, error) {
+	line := p.line
+	start := p.pos + 1
+	for p.pos++; p.pos < len(p.text); p.pos++ {
+		switch p.text[p.pos] {
+		case delim:
+			p.pos++
+			if delim == '\n' {
+				p.line++
+			}
+			return p.text[start : p.pos-1], nil
+		case '\n':
+			p.line++
+		}
+	}
+	if delim == '\n' {
+		return p.text[start:], nil
+	}
+	return "", fmt.Errorf("invalid pgn: line %d: unterminated comment", line)
+}
+
+func (p *pgnParser) readToken() string {
+	start := p.pos
+	for p.pos < len(p.text) {
+		c := rune(p.text[p.pos])
+		if unicode.IsSpace(c) || strings.ContainsRune(`{}()[];"$`, c) {
+			break
+		}
+		p.pos++
+	}
+	return p.text[start:p.pos]
+}
+
+// skip white space and escaped lines starting with "%"
+func (p *pgnParser) skipSpace() {
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		if c == '%' && (p.pos == 0 || p.text[p.pos-1] == '\n') {
+			p.readUntil('\n')
+			continue
+		}
+		if !unicode.IsSpace(rune(c)) {
+			return
+		}
+		if c == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+func (p *pgnParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid pgn: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+/*
+Replay the mainline of the game through MakeMove with the rules of the Variant tag,
+starting from the FEN tag if there is one.
+The result of the file is applied when the moves don't end the game by themselves
+*/
+func (pg *PGNGame) Replay(playerIds [2]string) (*Game, error) {
+	v, err := VariantByName(pg.Tags["Variant"])
+	if err != nil {
+		return nil, err
+	}
+	g, err := initVariantGame(playerIds, v, pg.Tags["FEN"])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range pg.Moves {
+		label := strconv.Itoa(g.fullmoveNumber) + ". " + m.SAN
+		if !g.isWhiteTurn {
+			label = strconv.Itoa(g.fullmoveNumber) + "... " + m.SAN
+		}
+		startPos, endPos, err := g.ParseSAN(m.SAN)
+		if err != nil {
+			return nil, fmt.Errorf("illegal move %s: %w", label, err)
+		}
+		if err := g.MakeMove(g.currentPlayerId(), startPos, endPos); err != nil {
+			return nil, fmt.Errorf("illegal move %s: %w", label, err)
+		}
+	}
+
+	result := pg.Result
+	if result == "*" && pg.Tags["Result"] != "" {
+		result = pg.Tags["Result"]
+	}
+	if g.IsOver() {
+		if result != "*" && result != g.Result() {
+			return nil, fmt.Errorf("result %s doesn't match the final position: %s", result, g.status)
+		}
+		return g, nil
+	}
+	switch result {
+	case "1-0":
+		g.status = whiteWins
+	case "0-1":
+		g.status = blackWins
+	case "1/2-1/2":
+		g.status = draw
+	}
+
+	return g, nil
+}
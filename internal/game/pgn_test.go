@@ -0,0 +1,218 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPGN(t *testing.T) {
+	igame, err := RestoreGame(generatePlayerIds(), "", "",
+		[]string{"e2-e4", "e7-e5", "f1-c4", "b8-c6", "d1-h5", "g8-f6", "h5-f7"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := igame.PGN(PGNTags{
+		Event: "Casual game",
+		Site:  "go-chess-server",
+		Date:  "2024.06.28",
+		Round: "-",
+		White: "alice",
+		Black: `bob "the rook"`,
+	})
+	want := `[Event "Casual game"]
+[Site "go-chess-server"]
+[Date "2024.06.28"]
+[Round "-"]
+[White "alice"]
+[Black "bob \"the rook\""]
+[Result "1-0"]
+[Termination "normal"]
+
+1. e4 e5 2. Bc4 Nc6 3. Qh5 Nf6 4. Qxf7# {White wins by checkmate.} 1-0
+`
+	if got != want {
+		t.Errorf("Test pgn: got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPGNFromFEN(t *testing.T) {
+	igame, err := InitGameFromFEN(generatePlayerIds(), "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, p2 := igame.GetPlayerIds()
+	for _, m := range []struct{ player, start, end string }{
+		{p2, "e8", "d7"}, {p1, "e2", "e4"}, {p2, "d7", "e6"},
+	} {
+		if err := igame.MakeMove(m.player, m.start, m.end); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := igame.PGN(PGNTags{})
+	for _, want := range []string{
+		`[Event "?"]`,
+		`[Result "*"]`,
+		`[SetUp "1"]`,
+		`[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"]`,
+		`[Termination "unterminated"]`,
+		"40... Kd7 41. e4 Ke6 *",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Test pgn from fen: missing %q in\n%s", want, got)
+		}
+	}
+}
+
+func TestPGNTermination(t *testing.T) {
+	for _, tc := range []struct {
+		fen         string
+		white       bool
+		termination string
+	}{
+		{StartingFEN, true, "time forfeit"},
+		{StartingFEN, false, "time forfeit"},
+		{"4k3/8/8/8/8/8/8/4KQ2 w - - 0 1", true, "time forfeit"},
+	} {
+		igame, err := InitGameFromFEN(generatePlayerIds(), tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := igame.Timeout(tc.white); err != nil {
+			t.Fatal(err)
+		}
+		want := `[Termination "` + tc.termination + `"]`
+		if pgn := igame.PGN(PGNTags{}); !strings.Contains(pgn, want) {
+			t.Errorf("Test pgn termination: %s, missing %q in\n%s", igame.GetStatus(), want, pgn)
+		}
+	}
+}
+
+func TestRestoreGame(t *testing.T) {
+	igame, err := RestoreGame(generatePlayerIds(), "", "", []string{"e2-e4", "e7-e5"}, "WHITE_RESIGN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if igame.Result() != "0-1" {
+		t.Errorf("Test restore game: got result %s, want 0-1", igame.Result())
+	}
+
+	checkmate := []string{"f2-f3", "e7-e5", "g2-g4", "d8-h4"}
+	if _, err := RestoreGame(generatePlayerIds(), "", "", checkmate, "WHITE_RESIGN"); err == nil {
+		t.Error("Test restore game: want error for status not matching the moves")
+	}
+	if _, err := RestoreGame(generatePlayerIds(), "", "", []string{"e2-e5"}, ""); err == nil {
+		t.Error("Test restore game: want error for illegal move")
+	}
+	if _, err := RestoreGame(generatePlayerIds(), "", "", nil, "WHITE_VICTORY"); err == nil {
+		t.Error("Test restore game: want error for unknown status")
+	}
+}
+
+const testPGN = `% exported by a tournament manager
+[Event "Club championship"]
+[White "Carlsen, M."]
+[Black "Doe, \"J\""]
+[Result "1-0"]
+
+{Opening comment} 1. e4 e5 2. Nf3 $1 Nc6 (2... d6 {Philidor} 3. d4 (3. Bc4)) 3.Bb5 a6?!
+4. Ba4 Nf6 5. O-O ; castles
+Be7 1-0
+
+[Event "Blitz"]
+[Result "1/2-1/2"]
+
+1. d4 d5 1/2-1/2
+`
+
+func TestParsePGN(t *testing.T) {
+	games, err := ParsePGN(testPGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("Test parse pgn: got %d games, want 2", len(games))
+	}
+
+	first := games[0]
+	if first.Tags["Black"] != `Doe, "J"` || first.Result != "1-0" {
+		t.Errorf("Test parse pgn: got tags %v and result %s", first.Tags, first.Result)
+	}
+	sans := []string{}
+	for _, m := range first.Moves {
+		sans = append(sans, m.SAN)
+	}
+	if got, want := strings.Join(sans, " "), "e4 e5 Nf3 Nc6 Bb5 a6 Ba4 Nf6 O-O Be7"; got != want {
+		t.Errorf("Test parse pgn: got moves %s, want %s", got, want)
+	}
+	if len(first.Comments) != 1 || first.Comments[0] != "Opening comment" {
+		t.Errorf("Test parse pgn: got game comments %v", first.Comments)
+	}
+	if nags := first.Moves[2].NAGs; len(nags) != 1 || nags[0] != 1 {
+		t.Errorf("Test parse pgn: got nags %v for Nf3, want [1]", nags)
+	}
+	if nags := first.Moves[5].NAGs; len(nags) != 1 || nags[0] != 6 {
+		t.Errorf("Test parse pgn: got nags %v for a6, want [6]", nags)
+	}
+	if v := first.Moves[3].Variations; len(v) != 1 || v[0] != "2... d6 {Philidor} 3. d4 (3. Bc4)" {
+		t.Errorf("Test parse pgn: got variations %q for Nc6", v)
+	}
+	if c := first.Moves[8].Comments; len(c) != 1 || c[0] != "castles" {
+		t.Errorf("Test parse pgn: got comments %q for O-O", c)
+	}
+
+	if games[1].Tags["Event"] != "Blitz" || len(games[1].Moves) != 2 || games[1].Result != "1/2-1/2" {
+		t.Errorf("Test parse pgn: got second game %+v", games[1])
+	}
+
+	for _, invalid := range []string{`[Event "open`, "1. e4 {comment", "1. e4 (1. d4", "1. e4 e5)"} {
+		if _, err := ParsePGN(invalid); err == nil {
+			t.Errorf("Test parse pgn: want error for %q", invalid)
+		}
+	}
+}
+
+func TestPGNReplay(t *testing.T) {
+	games, err := ParsePGN(testPGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	igame, err := games[0].Replay(generatePlayerIds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "WHITE_WINS" || igame.Result() != "1-0" {
+		t.Errorf("Test pgn replay: got status %s", igame.GetStatus())
+	}
+
+	// the exported game reads back the same
+	exported, err := ParsePGN(igame.PGN(PGNTags{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := exported[0].Replay(generatePlayerIds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(replayed.GetAllMoves(), " ") != strings.Join(igame.GetAllMoves(), " ") {
+		t.Errorf("Test pgn replay: got %v, want %v", replayed.GetAllMoves(), igame.GetAllMoves())
+	}
+
+	illegal, err := ParsePGN("1. e4 e5 2. Ke3 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := illegal[0].Replay(generatePlayerIds()); err == nil || !strings.Contains(err.Error(), "2. Ke3") {
+		t.Errorf("Test pgn replay: got error %v, want illegal move 2. Ke3", err)
+	}
+
+	mismatch, err := ParsePGN("1. f3 e5 2. g4 Qh4# 1-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mismatch[0].Replay(generatePlayerIds()); err == nil {
+		t.Error("Test pgn replay: want error for result not matching the checkmate")
+	}
+}
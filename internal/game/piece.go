@@ -4,4 +4,5 @@ type piece interface {
 	canMove(b *board, start *spot, end *spot) bool
 	isWhite() bool
 	toUnicode() string
+	toFEN() string
 }
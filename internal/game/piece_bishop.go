@@ -11,7 +11,8 @@ import (
  * Bishop
  */
 type bishop struct {
-	white bool
+	white    bool
+	promoted bool // promoted from a pawn, pocketed as a pawn once captured
 }
 
 func (b *bishop) canMove(board *board, start *spot, end *spot) bool {
@@ -78,3 +79,11 @@ func (b bishop) toUnicode() string {
 		return "♗"
 	}
 }
+
+func (b bishop) toFEN() string {
+	if b.white {
+		return "B"
+	} else {
+		return "b"
+	}
+}
@@ -85,6 +85,14 @@ func (k king) toUnicode() string {
 	}
 }
 
+func (k king) toFEN() string {
+	if k.white {
+		return "K"
+	} else {
+		return "k"
+	}
+}
+
 func isCastlingMove(sx, sy, ex, ey int) bool {
 	return sx == 4 && ey == sy && (ex == 0 || ex == 7) && (sy == 0 || sy == 7)
 }
@@ -8,7 +8,8 @@ import (
  * Knight
  */
 type knight struct {
-	white bool
+	white    bool
+	promoted bool // promoted from a pawn, pocketed as a pawn once captured
 }
 
 func (k *knight) canMove(board *board, start *spot, end *spot) bool {
@@ -38,3 +39,11 @@ func (k knight) toUnicode() string {
 		return "♘"
 	}
 }
+
+func (k knight) toFEN() string {
+	if k.white {
+		return "N"
+	} else {
+		return "n"
+	}
+}
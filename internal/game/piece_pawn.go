@@ -63,8 +63,21 @@ func (p pawn) canEnpassant(start *spot, end *spot, lastMove *move) bool {
 	if lastMove == nil {
 		return false
 	}
-	_, ok := lastMove.pieceMoved.(*pawn)
-	return ok && lastMove.isInitMove && lastMove.end.y == start.y && lastMove.end.x == end.x
+	if _, ok := lastMove.pieceMoved.(*pawn); !ok || !lastMove.isInitMove {
+		return false
+	}
+
+	// only a 2 step init move can be taken en passant
+	if math.Abs(float64(lastMove.end.y-lastMove.start.y)) != 2.0 {
+		return false
+	}
+
+	direction := 1
+	if !p.white {
+		direction = -1
+	}
+	return lastMove.end.y == start.y && lastMove.end.x == end.x &&
+		end.y == start.y+direction && math.Abs(float64(end.x-start.x)) == 1.0
 }
 
 func (p pawn) isWhite() bool {
@@ -79,16 +92,26 @@ func (p pawn) toUnicode() string {
 	}
 }
 
+func (p pawn) toFEN() string {
+	if p.white {
+		return "P"
+	} else {
+		return "p"
+	}
+}
+
 func (p pawn) promote(pieceName string) piece {
 	switch pieceName {
 	case "bishop":
-		return &bishop{white: p.white}
+		return &bishop{white: p.white, promoted: true}
 	case "knight":
-		return &knight{white: p.white}
+		return &knight{white: p.white, promoted: true}
 	case "rook":
-		return &rook{white: p.white}
+		return &rook{white: p.white, promoted: true}
 	case "queen":
-		return &queen{white: p.white}
+		return &queen{white: p.white, promoted: true}
+	case "king":
+		return &king{white: p.white, initMoved: true}
 	default:
 		logging.Error("Pawn promoted to UNDEFINED")
 		return nil
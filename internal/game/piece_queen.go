@@ -10,7 +10,8 @@ import (
  * Queen
  */
 type queen struct {
-	white bool
+	white    bool
+	promoted bool // promoted from a pawn, pocketed as a pawn once captured
 }
 
 func (q *queen) canMove(board *board, start *spot, end *spot) bool {
@@ -76,3 +77,11 @@ func (q queen) toUnicode() string {
 		return "♕"
 	}
 }
+
+func (q queen) toFEN() string {
+	if q.white {
+		return "Q"
+	} else {
+		return "q"
+	}
+}
@@ -13,6 +13,7 @@ import (
 type rook struct {
 	white     bool
 	initMoved bool
+	promoted  bool // promoted from a pawn, pocketed as a pawn once captured
 }
 
 func (r *rook) canMove(board *board, start *spot, end *spot) bool {
@@ -78,3 +79,11 @@ func (r rook) toUnicode() string {
 		return "♖"
 	}
 }
+
+func (r rook) toFEN() string {
+	if r.white {
+		return "R"
+	} else {
+		return "r"
+	}
+}
@@ -0,0 +1,421 @@
+package game
+
+type pieceKind uint8
+
+const (
+	kindPawn pieceKind = iota
+	kindKnight
+	kindBishop
+	kindRook
+	kindQueen
+	kindKing
+)
+
+/*
+ * Position
+ * Bitboard copy of the game state used for move generation and check detection.
+ * It is built from the board when needed and dropped whenever the board changes
+ */
+type position struct {
+	pieces      [2][6]bitboard // indexed by color, white first, and piece kind
+	occupied    [2]bitboard
+	whiteToMove bool
+	castling    int    // castling rights in the order K, Q, k, q, as in castlingMask
+	rooks       [4]int // castling rook squares in the same order
+	enpassant   int    // en passant target square, -1 if there is none
+	pockets     [2][kindKing]int
+	promoted    bitboard // pieces promoted from pawns, pocketed as pawns once captured
+	checks      [2]int   // checks given by each side, for Three-check
+	variant     Variant
+
+	generated bool
+	pseudo    []posMove // moves that may leave the own king in check
+	legal     []posMove
+}
+
+type posMove struct {
+	from      uint8
+	to        uint8     // rook square for castling
+	promotion pieceKind // kindPawn unless promoting
+	castling  bool
+	enpassant bool
+	drop      bool      // from is unused, the piece comes from the pocket
+	dropped   pieceKind // piece dropped on to
+}
+
+var promotionKinds = []pieceKind{kindQueen, kindRook, kindBishop, kindKnight}
+
+// piece kind of each promotion piece name taken by MakeMove
+var promotionKindNames = map[string]pieceKind{
+	"queen":  kindQueen,
+	"rook":   kindRook,
+	"bishop": kindBishop,
+	"knight": kindKnight,
+	"king":   kindKing,
+}
+
+func colorIndex(white bool) int {
+	if white {
+		return 0
+	}
+	return 1
+}
+
+func kindOf(p piece) pieceKind {
+	switch p.(type) {
+	case *pawn:
+		return kindPawn
+	case *knight:
+		return kindKnight
+	case *bishop:
+		return kindBishop
+	case *rook:
+		return kindRook
+	case *queen:
+		return kindQueen
+	default:
+		return kindKing
+	}
+}
+
+func newPosition(g *Game) *position {
+	pos := &position{
+		whiteToMove: g.isWhiteTurn,
+		castling:    g.castlingMask(),
+		enpassant:   -1,
+		pockets:     g.pockets,
+		checks:      g.checks,
+		variant:     g.variant,
+	}
+	for i, x := range g.castlingRooks {
+		pos.rooks[i] = squareIndex(x, 7*(i/2))
+	}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			if p := g.board.boxes[x][y].piece; p != nil {
+				pos.put(colorIndex(p.isWhite()), kindOf(p), squareIndex(x, y))
+				if isPromoted(p) {
+					pos.promoted |= squareBit(squareIndex(x, y))
+				}
+			}
+		}
+	}
+	if target := g.enpassantTarget(); target != "-" {
+		x, y := mapChessPosToCoord(target)
+		pos.enpassant = squareIndex(x, y)
+	}
+	return pos
+}
+
+/*
+Return the bitboard position of the game, built from the board on first use
+*/
+func (g *Game) position() *position {
+	if g.pos == nil {
+		g.pos = newPosition(g)
+	}
+	return g.pos
+}
+
+func (pos *position) put(color int, kind pieceKind, sq int) {
+	pos.pieces[color][kind] |= squareBit(sq)
+	pos.occupied[color] |= squareBit(sq)
+}
+
+func (pos *position) remove(color int, kind pieceKind, sq int) {
+	pos.pieces[color][kind] &^= squareBit(sq)
+	pos.occupied[color] &^= squareBit(sq)
+}
+
+func (pos *position) pieceAt(color, sq int) (pieceKind, bool) {
+	if pos.occupied[color]&squareBit(sq) == 0 {
+		return 0, false
+	}
+	for kind, b := range pos.pieces[color] {
+		if b&squareBit(sq) != 0 {
+			return pieceKind(kind), true
+		}
+	}
+	return 0, false
+}
+
+func (pos *position) side() int {
+	return colorIndex(pos.whiteToMove)
+}
+
+// check if any piece of the given color attacks the square
+func (pos *position) attacked(sq, by int) bool {
+	occupied := pos.occupied[0] | pos.occupied[1]
+	p := &pos.pieces[by]
+	return pawnAttacks[1-by][sq]&p[kindPawn] != 0 ||
+		knightAttacks[sq]&p[kindKnight] != 0 ||
+		kingAttacks[sq]&p[kindKing] != 0 ||
+		bishopAttacks(sq, occupied)&(p[kindBishop]|p[kindQueen]) != 0 ||
+		rookAttacks(sq, occupied)&(p[kindRook]|p[kindQueen]) != 0
+}
+
+func (pos *position) kingAttacked(white bool) bool {
+	color := colorIndex(white)
+	king := pos.pieces[color][kindKing]
+	if king == 0 || !pos.variant.royalKing() {
+		return false
+	}
+	return pos.attacked(king.first(), 1-color)
+}
+
+func (pos *position) inCheck() bool {
+	return pos.kingAttacked(pos.whiteToMove)
+}
+
+func (pos *position) legalMoves() []posMove {
+	pos.generate()
+	return pos.legal
+}
+
+func (pos *position) isLegal(from, to int) bool {
+	pos.generate()
+	return containsMove(pos.legal, from, to)
+}
+
+func (pos *position) isPseudoLegal(from, to int) bool {
+	pos.generate()
+	return containsMove(pos.pseudo, from, to)
+}
+
+func (pos *position) isLegalPromotion(from, to int, kind pieceKind) bool {
+	pos.generate()
+	for _, m := range pos.legal {
+		if !m.drop && int(m.from) == from && int(m.to) == to && m.promotion == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMove(moves []posMove, from, to int) bool {
+	for _, m := range moves {
+		if !m.drop && int(m.from) == from && int(m.to) == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (pos *position) generate() {
+	if pos.generated {
+		return
+	}
+	pos.generated = true
+
+	pos.pseudo = make([]posMove, 0, 96)
+	us, them := pos.side(), 1-pos.side()
+	own, enemy := pos.occupied[us], pos.occupied[them]
+	occupied := own | enemy
+
+	addTargets := func(from int, targets bitboard) {
+		for ; targets != 0; targets &= targets - 1 {
+			pos.pseudo = append(pos.pseudo, posMove{from: uint8(from), to: uint8(targets.first())})
+		}
+	}
+
+	forward, startRank := 8, 1
+	if us == 1 {
+		forward, startRank = -8, 6
+	}
+	for pawns := pos.pieces[us][kindPawn]; pawns != 0; pawns &= pawns - 1 {
+		from := pawns.first()
+		targets := pawnAttacks[us][from] & enemy
+		if one := from + forward; occupied&squareBit(one) == 0 {
+			targets |= squareBit(one)
+			if two := one + forward; from/8 == startRank && occupied&squareBit(two) == 0 {
+				targets |= squareBit(two)
+			}
+		}
+		for ; targets != 0; targets &= targets - 1 {
+			to := targets.first()
+			if squareBit(to)&(rank1|rank8) == 0 {
+				pos.pseudo = append(pos.pseudo, posMove{from: uint8(from), to: uint8(to)})
+				continue
+			}
+			for _, kind := range promotionKinds {
+				pos.pseudo = append(pos.pseudo, posMove{from: uint8(from), to: uint8(to), promotion: kind})
+			}
+		}
+		if pos.enpassant >= 0 && pawnAttacks[us][from]&squareBit(pos.enpassant) != 0 {
+			pos.pseudo = append(pos.pseudo, posMove{from: uint8(from), to: uint8(pos.enpassant), enpassant: true})
+		}
+	}
+
+	for b := pos.pieces[us][kindKnight]; b != 0; b &= b - 1 {
+		from := b.first()
+		addTargets(from, knightAttacks[from]&^own)
+	}
+	for b := pos.pieces[us][kindBishop]; b != 0; b &= b - 1 {
+		from := b.first()
+		addTargets(from, bishopAttacks(from, occupied)&^own)
+	}
+	for b := pos.pieces[us][kindRook]; b != 0; b &= b - 1 {
+		from := b.first()
+		addTargets(from, rookAttacks(from, occupied)&^own)
+	}
+	for b := pos.pieces[us][kindQueen]; b != 0; b &= b - 1 {
+		from := b.first()
+		addTargets(from, (bishopAttacks(from, occupied)|rookAttacks(from, occupied))&^own)
+	}
+	for b := pos.pieces[us][kindKing]; b != 0; b &= b - 1 {
+		from := b.first()
+		addTargets(from, kingAttacks[from]&^own)
+	}
+
+	pos.generateCastlings(occupied)
+
+	pos.legal = make([]posMove, 0, len(pos.pseudo))
+	king := pos.pieces[us][kindKing]
+	if king == 0 || !pos.variant.royalKing() {
+		pos.legal = pos.variant.filterMoves(pos, append(pos.legal, pos.pseudo...))
+		return
+	}
+	// out of check, only the king, en passant and pieces on a line with the king can expose it
+	kingSquare := king.first()
+	exposed := rookAttacks(kingSquare, 0) | bishopAttacks(kingSquare, 0) | king
+	if pos.attacked(kingSquare, them) {
+		exposed = ^bitboard(0)
+	}
+	for _, m := range pos.pseudo {
+		if exposed&squareBit(int(m.from)) == 0 && !m.enpassant {
+			pos.legal = append(pos.legal, m)
+			continue
+		}
+		if next := pos.play(m); !next.kingAttacked(pos.whiteToMove) {
+			pos.legal = append(pos.legal, m)
+		}
+	}
+	pos.legal = pos.variant.filterMoves(pos, pos.legal)
+}
+
+// castlings of the side to move, the king and the rook may start on any file as in Chess960
+func (pos *position) generateCastlings(occupied bitboard) {
+	us, them := pos.side(), 1-pos.side()
+	king := pos.pieces[us][kindKing]
+	if king == 0 {
+		return
+	}
+	from := king.first()
+
+	for i, rook := range pos.rooks {
+		if i/2 != us || pos.castling&(1<<i) == 0 {
+			continue
+		}
+		kingTo, rookTo := castlingTargets(from, rook)
+
+		// the squares crossed by the king and the rook must be empty but for themselves
+		path := rankSpan(from, kingTo) | rankSpan(rook, rookTo)
+		if occupied&path&^(squareBit(from)|squareBit(rook)) != 0 {
+			continue
+		}
+
+		// the king can't start from, pass through or land on an attacked square
+		safe := true
+		for b := rankSpan(from, kingTo); b != 0 && safe; b &= b - 1 {
+			safe = !pos.attacked(b.first(), them)
+		}
+		if safe {
+			pos.pseudo = append(pos.pseudo, posMove{from: uint8(from), to: uint8(rook), castling: true})
+		}
+	}
+}
+
+// squares the king and the rook land on, on the g and f files or on the c and d files
+func castlingTargets(king, rook int) (int, int) {
+	base := king - king%8
+	if rook > king {
+		return base + 6, base + 5
+	}
+	return base + 2, base + 3
+}
+
+// squares between a and b on the same rank, both included
+func rankSpan(a, b int) bitboard {
+	if a > b {
+		a, b = b, a
+	}
+	return (squareBit(b+1) - 1) &^ (squareBit(a) - 1)
+}
+
+// position after the move, the move isn't checked for legality
+func (pos *position) play(m posMove) position {
+	from, to := int(m.from), int(m.to)
+	next := position{
+		pieces:      pos.pieces,
+		occupied:    pos.occupied,
+		whiteToMove: !pos.whiteToMove,
+		castling:    pos.castling,
+		rooks:       pos.rooks,
+		enpassant:   -1,
+		pockets:     pos.pockets,
+		promoted:    pos.promoted,
+		checks:      pos.checks,
+		variant:     pos.variant,
+	}
+
+	us, them := pos.side(), 1-pos.side()
+	if m.drop {
+		next.put(us, m.dropped, to)
+		next.pockets[us][m.dropped]--
+		return next
+	}
+	kind, _ := pos.pieceAt(us, from)
+	next.remove(us, kind, from)
+
+	// castling rights are lost once the king or the rook moves or the rook is taken
+	if kind == kindKing {
+		next.castling &^= 3 << (2 * us)
+	}
+	for i, rook := range pos.rooks {
+		if from == rook || to == rook {
+			next.castling &^= 1 << i
+		}
+	}
+
+	switch {
+	case m.castling:
+		next.remove(us, kindRook, to)
+		kingTo, rookTo := castlingTargets(from, to)
+		next.put(us, kindKing, kingTo)
+		next.put(us, kindRook, rookTo)
+	case m.enpassant:
+		taken := to - 8
+		if us == 1 {
+			taken = to + 8
+		}
+		next.remove(them, kindPawn, taken)
+		next.put(us, kindPawn, to)
+		if pos.variant.pocketCaptures() {
+			next.pockets[us][kindPawn]++
+		}
+	default:
+		if taken, ok := pos.pieceAt(them, to); ok {
+			next.remove(them, taken, to)
+			if pos.promoted&squareBit(to) != 0 {
+				taken = kindPawn
+			}
+			if pos.variant.pocketCaptures() {
+				next.pockets[us][taken]++
+			}
+		}
+		next.promoted &^= squareBit(to)
+		if pos.promoted&squareBit(from) != 0 {
+			next.promoted = next.promoted&^squareBit(from) | squareBit(to)
+		}
+		if m.promotion != kindPawn {
+			kind = m.promotion
+			next.promoted |= squareBit(to)
+		}
+		next.put(us, kind, to)
+		if kind == kindPawn && (to-from == 16 || from-to == 16) {
+			next.enpassant = (from + to) / 2
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,41 @@
+package game
+
+import "errors"
+
+func (g *Game) initRepetitions() {
+	g.hash = g.computeHash()
+	g.repetitions = map[uint64]int{g.hash: 1}
+}
+
+// count the current position and end the game on the fifth occurrence
+func (g *Game) updateRepetitions() {
+	g.repetitions[g.hash]++
+	if g.status == active && g.repetitions[g.hash] >= 5 {
+		g.status = fivefoldRepetition
+	}
+}
+
+/*
+Return the number of times the current position has occurred in the game
+*/
+func (g *Game) RepetitionCount() int {
+	return g.repetitions[g.hash]
+}
+
+/*
+Claim a draw by threefold repetition for the player with the given id.
+The claim is valid once the current position has occurred at least three times
+*/
+func (g *Game) ClaimThreefoldRepetition(playerId string) error {
+	if _, err := g.GetPlayerSide(playerId); err != nil {
+		return err
+	}
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+	if g.RepetitionCount() < 3 {
+		return errors.New("position has not occurred three times")
+	}
+	g.status = threefoldRepetition
+	return nil
+}
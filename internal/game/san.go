@@ -0,0 +1,193 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var sanPattern = regexp.MustCompile(`^([NBRQK])?([a-h])?([1-8])?(x)?([a-h][1-8])(=?([NBRQKnbrqk]))?$`)
+
+/*
+Resolve a move in Standard Algebraic Notation (e.g. "Nf3", "exd5", "O-O", "e8=Q+", or "N@f3" for a drop)
+against the current position. The result can be passed to MakeMove
+*/
+func (g *Game) ParseSAN(san string) (startPos, endPos string, err error) {
+	san = strings.TrimRight(strings.TrimSpace(san), "+#!?")
+
+	if pos, ok := parseDrop(san); ok {
+		return pos[0], pos[1], nil
+	}
+	switch san {
+	case "O-O", "0-0":
+		return g.parseSANCastling(true)
+	case "O-O-O", "0-0-0":
+		return g.parseSANCastling(false)
+	}
+
+	matches := sanPattern.FindStringSubmatch(san)
+	if matches == nil {
+		return "", "", fmt.Errorf("couldn't parse san move: %s", san)
+	}
+
+	pieceLetter := matches[1]
+	if pieceLetter == "" {
+		pieceLetter = "P"
+	}
+	if !g.isWhiteTurn {
+		pieceLetter = strings.ToLower(pieceLetter)
+	}
+	fromFile, fromRank := matches[2], matches[3]
+	if pieceLetter == "P" || pieceLetter == "p" {
+		// pawns stay on their file unless capturing
+		if matches[4] == "" {
+			fromFile = matches[5][:1]
+		} else if fromFile == "" {
+			return "", "", fmt.Errorf("couldn't parse san move: %s", san)
+		}
+	}
+	endX, endY := mapChessPosToCoord(matches[5])
+	end := g.board.boxes[endX][endY]
+
+	candidates := []*spot{}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			box := g.board.boxes[x][y]
+			if box.piece == nil || box.piece.toFEN() != pieceLetter {
+				continue
+			}
+			if fromFile != "" && int(fromFile[0]-'a') != x {
+				continue
+			}
+			if fromRank != "" && int(fromRank[0]-'1') != y {
+				continue
+			}
+			if g.isLegalMove(box, end) {
+				candidates = append(candidates, box)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", "", fmt.Errorf("illegal san move: %s", san)
+	}
+	if len(candidates) > 1 {
+		return "", "", fmt.Errorf("ambiguous san move: %s", san)
+	}
+
+	start := candidates[0]
+	endPos = matches[5]
+	if matches[7] != "" {
+		endPos += strings.ToLower(matches[7])
+	}
+	return mapCoordToChessPos(start.x, start.y), endPos, nil
+}
+
+// castling as the king-to-rook move, the rook being the one the castling right was given for
+func (g *Game) parseSANCastling(kingside bool) (string, string, error) {
+	side := 0
+	if !g.isWhiteTurn {
+		side = 1
+	}
+	right := 2 * side
+	if !kingside {
+		right++
+	}
+	start := g.kingSpots[side]
+	end := g.board.boxes[g.castlingRooks[right]][7*side]
+	if start == nil || start.y != end.y || !g.isLegalMove(start, end) {
+		return "", "", errors.New("illegal castling")
+	}
+	return mapCoordToChessPos(start.x, start.y), mapCoordToChessPos(end.x, end.y), nil
+}
+
+// file, rank or square needed to tell the move apart from the same piece type moving to the same spot
+func (g *Game) sanDisambiguation(start, end *spot) string {
+	if _, ok := start.piece.(*pawn); ok {
+		return ""
+	}
+
+	ambiguous, sameFile, sameRank := false, false, false
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			box := g.board.boxes[x][y]
+			if box == start || box.piece == nil || box.piece.toFEN() != start.piece.toFEN() {
+				continue
+			}
+			if !g.isLegalMove(box, end) {
+				continue
+			}
+			ambiguous = true
+			if box.x == start.x {
+				sameFile = true
+			}
+			if box.y == start.y {
+				sameRank = true
+			}
+		}
+	}
+
+	pos := mapCoordToChessPos(start.x, start.y)
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return pos[:1]
+	case !sameRank:
+		return pos[1:]
+	default:
+		return pos
+	}
+}
+
+func (m *move) toSAN(mate bool) string {
+	var san strings.Builder
+
+	if m.isDrop {
+		san.WriteString(m.startPos + m.endPos)
+	} else if m.isCastling {
+		if m.end.x < m.start.x {
+			san.WriteString("O-O-O")
+		} else {
+			san.WriteString("O-O")
+		}
+	} else {
+		isCapture := m.pieceTaken != nil || m.isEnpassant
+		if _, ok := m.pieceMoved.(*pawn); ok {
+			if isCapture {
+				san.WriteString(m.startPos[:1])
+			}
+		} else {
+			san.WriteString(strings.ToUpper(m.pieceMoved.toFEN()))
+			san.WriteString(m.disambiguation)
+		}
+		if isCapture {
+			san.WriteString("x")
+		}
+		san.WriteString(m.endPos)
+		if m.piecePromoted != nil {
+			san.WriteString("=" + strings.ToUpper(m.piecePromoted.toFEN()))
+		}
+	}
+
+	if mate {
+		san.WriteString("#")
+	} else if m.isChecking {
+		san.WriteString("+")
+	}
+
+	return san.String()
+}
+
+/*
+Return all moves played in the game in Standard Algebraic Notation
+*/
+func (g *Game) GetAllMovesSAN() []string {
+	res := make([]string, 0, len(g.moves))
+	for i, move := range g.moves {
+		mate := i == len(g.moves)-1 && (g.status == whiteCheckmate || g.status == blackCheckmate)
+		res = append(res, move.toSAN(mate))
+	}
+	return res
+}
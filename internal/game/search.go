@@ -0,0 +1,211 @@
+package game
+
+import "strings"
+
+/*
+ * SearchPosition
+ * Copy of a game position for engines to search. Moves are generated and played
+ * on bitboards without touching the game, and positions are hashed the way the
+ * game hashes them, so repetitions of the positions of the game can be told
+ */
+type SearchPosition struct {
+	pos           position
+	status        GameStatus
+	hash          uint64
+	halfmoveClock int
+	history       map[uint64]int // positions of the game, shared by the positions played from it
+}
+
+/*
+ * SearchMove
+ * Legal move of a search position
+ */
+type SearchMove struct {
+	m posMove
+}
+
+// FEN letters of the pieces by color and piece kind
+const pieceLetters = "PNBRQKpnbrqk"
+
+func pieceLetter(color int, kind pieceKind) byte {
+	return pieceLetters[color*6+int(kind)]
+}
+
+/*
+Return the current position of the game to search. It doesn't change as the game goes on
+*/
+func (g *Game) SearchPosition() SearchPosition {
+	history := make(map[uint64]int, len(g.repetitions))
+	for hash, n := range g.repetitions {
+		history[hash] = n
+	}
+	return SearchPosition{
+		pos:           *g.position(),
+		status:        g.status,
+		hash:          g.hash,
+		halfmoveClock: g.halfmoveClock,
+		history:       history,
+	}
+}
+
+/*
+Return the legal moves of the side to move, none once the game is over
+*/
+func (p *SearchPosition) Moves() []SearchMove {
+	if p.IsOver() {
+		return nil
+	}
+	legal := p.pos.legalMoves()
+	moves := make([]SearchMove, len(legal))
+	for i, m := range legal {
+		moves[i] = SearchMove{m}
+	}
+	return moves
+}
+
+/*
+Return the position after a legal move of the position. The game ends in it
+as it would after the same move in the game, draws by repetition aside
+*/
+func (p *SearchPosition) Play(m SearchMove) SearchPosition {
+	next := SearchPosition{
+		pos:           p.pos.play(m.m),
+		halfmoveClock: p.halfmoveClock + 1,
+		history:       p.history,
+	}
+	if _, ok := p.pos.captured(m.m); ok || p.pos.moved(m.m) == kindPawn {
+		next.halfmoveClock = 0
+	}
+	if next.pos.inCheck() {
+		next.pos.checks[p.pos.side()]++
+	}
+	next.hash = next.pos.hash()
+
+	next.status = next.pos.variant.terminalStatus(&next.pos)
+	if next.status == active && next.halfmoveClock >= seventyFiveMoveHalfmoves {
+		next.status = seventyFiveMoveRule
+	}
+	return next
+}
+
+/*
+Return the zobrist hash of the position, the same as Game.Hash gives it
+*/
+func (p *SearchPosition) Hash() uint64 {
+	return p.hash
+}
+
+/*
+Return the number of times the position occurred in the game it was taken from,
+0 for positions only reached by playing on it
+*/
+func (p *SearchPosition) RepetitionCount() int {
+	return p.history[p.hash]
+}
+
+/*
+Check if white is to move in the position
+*/
+func (p *SearchPosition) WhiteToMove() bool {
+	return p.pos.whiteToMove
+}
+
+/*
+Check if the game is over in the position
+*/
+func (p *SearchPosition) IsOver() bool {
+	return p.status != active
+}
+
+/*
+Return the result of the position as written in PGN, "*" while the game goes on
+*/
+func (p *SearchPosition) Result() string {
+	result, _ := variantResult(p.pos.variant, p.status)
+	return result
+}
+
+/*
+Return the pieces of the position by square, a1 first, as FEN letters with 0 for empty squares
+*/
+func (p *SearchPosition) Board() [64]byte {
+	var squares [64]byte
+	for color := range p.pos.pieces {
+		for kind, b := range p.pos.pieces[color] {
+			for ; b != 0; b &= b - 1 {
+				squares[b.first()] = pieceLetter(color, pieceKind(kind))
+			}
+		}
+	}
+	return squares
+}
+
+/*
+Return the pieces in hand of both sides as FEN letters, white's first, e.g. "QPn"
+*/
+func (p *SearchPosition) Pockets() string {
+	var pockets strings.Builder
+	for color := range p.pos.pockets {
+		for kind, n := range p.pos.pockets[color] {
+			for i := 0; i < n; i++ {
+				pockets.WriteByte(pieceLetter(color, pieceKind(kind)))
+			}
+		}
+	}
+	return pockets.String()
+}
+
+/*
+Return the FEN letter of the piece the move takes, including the pawn taken
+en passant, 0 if it takes none
+*/
+func (p *SearchPosition) Captured(m SearchMove) byte {
+	kind, ok := p.pos.captured(m.m)
+	if !ok {
+		return 0
+	}
+	return pieceLetter(1-p.pos.side(), kind)
+}
+
+/*
+Return the FEN letter of the piece moved, or dropped, by the move
+*/
+func (p *SearchPosition) Moved(m SearchMove) byte {
+	return pieceLetter(p.pos.side(), p.pos.moved(m.m))
+}
+
+/*
+Return the move in the form of "e2-e4", as listed by Game.LegalMoves
+*/
+func (m SearchMove) String() string {
+	return m.m.String()
+}
+
+/*
+Return the piece promoted to as a lowercase FEN letter, e.g. 'q', 0 if the move isn't a promotion
+*/
+func (m SearchMove) Promotion() byte {
+	if m.m.promotion == kindPawn {
+		return 0
+	}
+	return kindSuffixes[m.m.promotion][0]
+}
+
+// piece taken by the move, castling moves the king onto its own rook
+func (pos *position) captured(m posMove) (pieceKind, bool) {
+	switch {
+	case m.drop, m.castling:
+		return 0, false
+	case m.enpassant:
+		return kindPawn, true
+	}
+	return pos.pieceAt(1-pos.side(), int(m.to))
+}
+
+func (pos *position) moved(m posMove) pieceKind {
+	if m.drop {
+		return m.dropped
+	}
+	kind, _ := pos.pieceAt(pos.side(), int(m.from))
+	return kind
+}
@@ -0,0 +1,61 @@
+package game
+
+import "testing"
+
+func TestSearchPosition(t *testing.T) {
+	testcases := []struct {
+		name    string
+		variant Variant
+		fen     string
+		moves   []string
+		want    string
+	}{
+		{"castling and en passant", Standard, StartingFEN,
+			[]string{"e2-e4", "g8-f6", "e4-e5", "d7-d5", "e5-d6", "e7-d6", "g1-f3", "f8-e7", "f1-e2", "e8-h8", "e1-h1"}, "*"},
+		{"three check", ThreeCheck, "4k3/8/8/8/8/8/8/R3K3 w - - 0 1",
+			[]string{"a1-a8", "e8-e7", "a8-a7", "e7-e6", "a7-a6"}, "1-0"},
+		{"drops", Crazyhouse, "",
+			[]string{"e2-e4", "d7-d5", "e4-d5", "d8-d5", "b1-c3", "d5-a5", "P@d4", "P@e4"}, "*"},
+		{"checkmate", Standard, StartingFEN,
+			[]string{"f2-f3", "e7-e5", "g2-g4", "d8-h4"}, "0-1"},
+	}
+	for _, tc := range testcases {
+		igame, err := initVariantGame(generatePlayerIds(), tc.variant, tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos := igame.SearchPosition()
+		for _, move := range tc.moves {
+			var m SearchMove
+			found := false
+			for _, legal := range pos.Moves() {
+				if legal.String() == move {
+					m, found = legal, true
+				}
+			}
+			if !found {
+				t.Fatalf("Test %s: %s not in the moves of the search position", tc.name, move)
+			}
+			if move == "e5-d6" && pos.Captured(m) != 'p' {
+				t.Errorf("Test %s: en passant captured %q", tc.name, pos.Captured(m))
+			}
+
+			pos = pos.Play(m)
+			startPos, endPos := m.m.positions()
+			if err := igame.makeMove(igame.currentPlayerId(), startPos, endPos); err != nil {
+				t.Fatal(err)
+			}
+			if pos.Hash() != igame.Hash() || pos.IsOver() != igame.IsOver() {
+				t.Errorf("Test %s: after %s got hash %x, over %t, want %x, %t",
+					tc.name, move, pos.Hash(), pos.IsOver(), igame.Hash(), igame.IsOver())
+			}
+		}
+		if pos.Result() != tc.want || pos.Result() != igame.Result() {
+			t.Errorf("Test %s: got result %s, want %s", tc.name, pos.Result(), tc.want)
+		}
+		current := igame.SearchPosition()
+		if pos.RepetitionCount() != 0 || current.RepetitionCount() != 1 {
+			t.Errorf("Test %s: got repetition counts %d and %d", tc.name, pos.RepetitionCount(), current.RepetitionCount())
+		}
+	}
+}
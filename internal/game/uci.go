@@ -0,0 +1,55 @@
+package game
+
+// map a castling move given as the king's destination onto the king-to-rook move
+func (g *Game) normalizeCastling(startPos, endPos string) string {
+	// Chess960 castling is always given as the king-to-rook move
+	if g.chess960 || !isSquare(startPos) || !isSquare(endPos) {
+		return endPos
+	}
+
+	startX, startY := mapChessPosToCoord(startPos)
+	endX, endY := mapChessPosToCoord(endPos)
+	if _, ok := g.board.boxes[startX][startY].piece.(*king); !ok {
+		return endPos
+	}
+	if startX != 4 || startY != endY || (startY != 0 && startY != 7) {
+		return endPos
+	}
+
+	switch endX {
+	case 6:
+		return mapCoordToChessPos(7, endY)
+	case 2:
+		return mapCoordToChessPos(0, endY)
+	default:
+		return endPos
+	}
+}
+
+// the move in UCI long algebraic notation, castling is written as the king's destination
+// except in Chess960 where it stays the king-to-rook move
+func (m *move) toUCI(chess960 bool) string {
+	endPos := m.endPos
+	if m.isDrop {
+		return m.startPos + endPos
+	}
+	if m.isCastling && !chess960 {
+		if m.end.x < m.start.x {
+			endPos = mapCoordToChessPos(2, m.end.y)
+		} else {
+			endPos = mapCoordToChessPos(6, m.end.y)
+		}
+	}
+	return m.startPos + endPos + promotionSuffix(m.piecePromoted)
+}
+
+/*
+Return all moves played in the game in UCI long algebraic notation
+*/
+func (g *Game) GetAllMovesUCI() []string {
+	res := make([]string, 0, len(g.moves))
+	for _, move := range g.moves {
+		res = append(res, move.toUCI(g.chess960))
+	}
+	return res
+}
@@ -0,0 +1,75 @@
+package game
+
+import "errors"
+
+func (g *Game) saveUndoState(move *move) {
+	move.prevKingSpots = g.kingSpots
+	move.prevStatus = g.status
+	move.prevHalfmoveClock = g.halfmoveClock
+	move.prevFullmoveNumber = g.fullmoveNumber
+	move.prevHash = g.hash
+	move.prevPockets = g.pockets
+}
+
+/*
+Take back the last move played in the game, restoring the previous position
+including castling rights, en passant state, move counters and game status
+*/
+func (g *Game) UndoMove() error {
+	if len(g.moves) == 0 {
+		return errors.New("no move to undo")
+	}
+
+	move := g.moves[len(g.moves)-1]
+	g.moves = g.moves[:len(g.moves)-1]
+
+	// restore the board
+	if move.isCastling {
+		kingSpot := g.kingSpots[0]
+		if !move.pieceMoved.isWhite() {
+			kingSpot = g.kingSpots[1]
+		}
+		kingSpot.piece = nil
+		move.castlingRookSpot.piece = nil
+	}
+	move.end.piece = move.pieceTaken
+	if !move.isDrop {
+		move.start.piece = move.pieceMoved
+	}
+	if move.isEnpassant {
+		move.enpassantSpot.piece = move.enpassantTaken
+	}
+
+	// the piece moved for the first time can make its init move again
+	if move.isInitMove {
+		switch p := move.pieceMoved.(type) {
+		case *pawn:
+			p.initMoved = false
+		case *king:
+			p.initMoved = false
+		case *rook:
+			p.initMoved = false
+		}
+	}
+
+	if move.isChecking {
+		g.checks[colorIndex(move.pieceMoved.isWhite())]--
+	}
+
+	g.repetitions[g.hash]--
+	if g.repetitions[g.hash] == 0 {
+		delete(g.repetitions, g.hash)
+	}
+
+	g.kingSpots = move.prevKingSpots
+	g.status = move.prevStatus
+	g.halfmoveClock = move.prevHalfmoveClock
+	g.fullmoveNumber = move.prevFullmoveNumber
+	g.hash = move.prevHash
+	g.pockets = move.prevPockets
+	g.isWhiteTurn = !g.isWhiteTurn
+	g.pos = nil
+	g.updateCheckFlags()
+
+	return nil
+}
@@ -0,0 +1,345 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+/*
+ * Variant
+ * Rules a game is played with: the starting position, the moves allowed
+ * on top of the moves of standard chess, the way the game ends and
+ * the names of the results it ends with
+ */
+type Variant interface {
+	// identifier used in matching requests and match records, e.g. "kingofthehill"
+	Name() string
+	// name written in the PGN Variant tag, e.g. "King of the Hill"
+	Title() string
+
+	// FEN of the position new games start from
+	startingFEN() string
+	// whether the king can't be left in check and can be checkmated
+	royalKing() bool
+	// whether captured pieces go to the capturer's pocket to be dropped back
+	pocketCaptures() bool
+	// moves the side to move may play, given the moves standard chess allows
+	filterMoves(pos *position, moves []posMove) []posMove
+	// status of the position after a move, active if the game goes on
+	terminalStatus(pos *position) GameStatus
+	// whether the side still has the material to win the game
+	canWin(pos *position, white bool) bool
+	// PGN result and reason of a status only the variant ends games with
+	describeStatus(status GameStatus) (result, reason string, ok bool)
+}
+
+// variant end states, named after the side that wins
+const (
+	whiteKingOfTheHill GameStatus = "WHITE_KING_OF_THE_HILL"
+	blackKingOfTheHill GameStatus = "BLACK_KING_OF_THE_HILL"
+	whiteThreeCheck    GameStatus = "WHITE_THREE_CHECK"
+	blackThreeCheck    GameStatus = "BLACK_THREE_CHECK"
+	whiteOutOfPieces   GameStatus = "WHITE_OUT_OF_PIECES"
+	blackOutOfPieces   GameStatus = "BLACK_OUT_OF_PIECES"
+	whiteStalemated    GameStatus = "WHITE_STALEMATED"
+	blackStalemated    GameStatus = "BLACK_STALEMATED"
+)
+
+var (
+	Standard      Variant = standardRules{}
+	Chess960      Variant = chess960Rules{}
+	KingOfTheHill Variant = kingOfTheHillRules{}
+	ThreeCheck    Variant = threeCheckRules{}
+	Antichess     Variant = antichessRules{}
+	Crazyhouse    Variant = crazyhouseRules{}
+
+	variants = []Variant{Standard, Chess960, KingOfTheHill, ThreeCheck, Antichess, Crazyhouse}
+)
+
+/*
+Return the variant with the given name or PGN title, ignoring case.
+An empty name stands for standard chess
+*/
+func VariantByName(name string) (Variant, error) {
+	if name == "" {
+		return Standard, nil
+	}
+	for _, v := range variants {
+		if strings.EqualFold(name, v.Name()) || strings.EqualFold(name, v.Title()) {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown variant: %s", name)
+}
+
+/*
+Initialize a game of the variant from its starting position
+*/
+func InitGameVariant(playerIds [2]string, v Variant) (*Game, error) {
+	return initVariantGame(playerIds, v, v.startingFEN())
+}
+
+// game of the variant from the position in FEN, or from the starting position of the variant if fen is empty
+func initVariantGame(playerIds [2]string, v Variant, fen string) (*Game, error) {
+	if fen == "" {
+		// every game starts from a different position
+		if v == Chess960 {
+			return nil, errors.New("missing starting position of chess960 game")
+		}
+		fen = v.startingFEN()
+	}
+
+	g, err := initGameFromFEN(playerIds, fen, v)
+	if err != nil {
+		return nil, err
+	}
+	if v == Chess960 && !g.chess960 {
+		// e.g. the standard position given with "KQkq" castling rights
+		g.chess960 = true
+		g.startFEN = g.FEN()
+	}
+	return g, nil
+}
+
+/*
+Return the variant the game is played with
+*/
+func (g *Game) Variant() Variant {
+	return g.variant
+}
+
+// status for the side to move under the standard rules
+func (pos *position) standardStatus() GameStatus {
+	if status := pos.mateStatus(); status != active {
+		return status
+	}
+	return pos.materialStatus()
+}
+
+// draw once neither side can win with its material under the rules of the variant
+func (pos *position) materialStatus() GameStatus {
+	if !pos.variant.canWin(pos, true) && !pos.variant.canWin(pos, false) {
+		return insufficientMaterial
+	}
+	return active
+}
+
+// checkmate or stalemate of the side to move, active if it has a legal move
+func (pos *position) mateStatus() GameStatus {
+	switch {
+	case len(pos.legalMoves()) > 0:
+		return active
+	case !pos.inCheck():
+		return stalemate
+	case pos.whiteToMove:
+		return blackCheckmate
+	}
+	return whiteCheckmate
+}
+
+// PGN result of the status, false if no game ends with it
+func (g *Game) statusResult(status GameStatus) (string, bool) {
+	return variantResult(g.variant, status)
+}
+
+func variantResult(v Variant, status GameStatus) (string, bool) {
+	if result, _, ok := v.describeStatus(status); ok {
+		return result, true
+	}
+	result, ok := statusResults[status]
+	return result, ok
+}
+
+// reason the game ended with the status, false if there is none to give
+func (g *Game) statusReason(status GameStatus) (string, bool) {
+	if _, reason, ok := g.variant.describeStatus(status); ok {
+		return reason, true
+	}
+	reason, ok := statusReasons[status]
+	return reason, ok
+}
+
+type standardRules struct{}
+
+func (standardRules) Name() string         { return "standard" }
+func (standardRules) Title() string        { return "Standard" }
+func (standardRules) startingFEN() string  { return StartingFEN }
+func (standardRules) royalKing() bool      { return true }
+func (standardRules) pocketCaptures() bool { return false }
+
+func (standardRules) filterMoves(pos *position, moves []posMove) []posMove {
+	return moves
+}
+
+func (standardRules) terminalStatus(pos *position) GameStatus {
+	return pos.standardStatus()
+}
+
+func (standardRules) canWin(pos *position, white bool) bool {
+	return !pos.insufficientMaterialFor(white)
+}
+
+func (standardRules) describeStatus(status GameStatus) (string, string, bool) {
+	return "", "", false
+}
+
+// Fischer Random: the pieces of the back rank are shuffled and castling is played king-to-rook
+type chess960Rules struct {
+	standardRules
+}
+
+func (chess960Rules) Name() string  { return "chess960" }
+func (chess960Rules) Title() string { return "Chess960" }
+
+func (chess960Rules) startingFEN() string {
+	// every position number is valid, so the error can't happen
+	fen, _ := chess960FEN(rand.Intn(960))
+	return fen
+}
+
+// the king reaching one of the four centre squares wins
+type kingOfTheHillRules struct {
+	standardRules
+}
+
+var hill = squareBit(27) | squareBit(28) | squareBit(35) | squareBit(36) // d4, e4, d5 and e5
+
+func (kingOfTheHillRules) Name() string  { return "kingofthehill" }
+func (kingOfTheHillRules) Title() string { return "King of the Hill" }
+
+func (kingOfTheHillRules) terminalStatus(pos *position) GameStatus {
+	switch {
+	case pos.pieces[0][kindKing]&hill != 0:
+		return whiteKingOfTheHill
+	case pos.pieces[1][kindKing]&hill != 0:
+		return blackKingOfTheHill
+	}
+	// material never runs out, see canWin
+	return pos.mateStatus()
+}
+
+// a lone king can still win by reaching the centre
+func (kingOfTheHillRules) canWin(pos *position, white bool) bool {
+	return true
+}
+
+func (kingOfTheHillRules) describeStatus(status GameStatus) (string, string, bool) {
+	switch status {
+	case whiteKingOfTheHill:
+		return "1-0", "White wins by bringing the king to the centre.", true
+	case blackKingOfTheHill:
+		return "0-1", "Black wins by bringing the king to the centre.", true
+	}
+	return "", "", false
+}
+
+// the third check given wins
+type threeCheckRules struct {
+	standardRules
+}
+
+const threeCheckLimit = 3
+
+func (threeCheckRules) Name() string  { return "threecheck" }
+func (threeCheckRules) Title() string { return "Three-check" }
+
+func (threeCheckRules) terminalStatus(pos *position) GameStatus {
+	switch {
+	case pos.checks[0] >= threeCheckLimit:
+		return whiteThreeCheck
+	case pos.checks[1] >= threeCheckLimit:
+		return blackThreeCheck
+	}
+	return pos.standardStatus()
+}
+
+// any piece besides the king can still give checks
+func (threeCheckRules) canWin(pos *position, white bool) bool {
+	side := colorIndex(white)
+	return pos.occupied[side]&^pos.pieces[side][kindKing] != 0
+}
+
+func (threeCheckRules) describeStatus(status GameStatus) (string, string, bool) {
+	switch status {
+	case whiteThreeCheck:
+		return "1-0", "White wins by giving three checks.", true
+	case blackThreeCheck:
+		return "0-1", "Black wins by giving three checks.", true
+	}
+	return "", "", false
+}
+
+/*
+ * Antichess
+ * Captures are compulsory and the king is an ordinary piece pawns can promote to.
+ * The side that loses all its pieces or has no move left wins
+ */
+type antichessRules struct{}
+
+func (antichessRules) Name() string         { return "antichess" }
+func (antichessRules) Title() string        { return "Antichess" }
+func (antichessRules) royalKing() bool      { return false }
+func (antichessRules) pocketCaptures() bool { return false }
+
+func (antichessRules) startingFEN() string {
+	return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1"
+}
+
+func (antichessRules) filterMoves(pos *position, moves []posMove) []posMove {
+	enemy := pos.occupied[1-pos.side()]
+	captures := make([]posMove, 0, len(moves))
+	for _, m := range moves {
+		if m.enpassant || enemy&squareBit(int(m.to)) != 0 {
+			captures = append(captures, m)
+		}
+	}
+	if len(captures) > 0 {
+		moves = captures
+	}
+
+	allowed := make([]posMove, 0, len(moves)+len(moves)/4)
+	for _, m := range moves {
+		allowed = append(allowed, m)
+		if m.promotion == kindQueen {
+			m.promotion = kindKing
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+func (antichessRules) terminalStatus(pos *position) GameStatus {
+	white := pos.whiteToMove
+	switch {
+	case pos.occupied[pos.side()] == 0 && white:
+		return whiteOutOfPieces
+	case pos.occupied[pos.side()] == 0:
+		return blackOutOfPieces
+	case len(pos.legalMoves()) == 0 && white:
+		return whiteStalemated
+	case len(pos.legalMoves()) == 0:
+		return blackStalemated
+	}
+	return active
+}
+
+// losing every piece wins, which no material prevents
+func (antichessRules) canWin(pos *position, white bool) bool {
+	return true
+}
+
+func (antichessRules) describeStatus(status GameStatus) (string, string, bool) {
+	switch status {
+	case whiteOutOfPieces:
+		return "1-0", "White wins by losing all pieces.", true
+	case blackOutOfPieces:
+		return "0-1", "Black wins by losing all pieces.", true
+	case whiteStalemated:
+		return "1-0", "White wins by being stalemated.", true
+	case blackStalemated:
+		return "0-1", "Black wins by being stalemated.", true
+	}
+	return "", "", false
+}
@@ -0,0 +1,325 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVariantByName(t *testing.T) {
+	for name, want := range map[string]Variant{
+		"":                 Standard,
+		"chess960":         Chess960,
+		"kingofthehill":    KingOfTheHill,
+		"King of the Hill": KingOfTheHill,
+		"Three-check":      ThreeCheck,
+		"ANTICHESS":        Antichess,
+		"Crazyhouse":       Crazyhouse,
+	} {
+		if got, err := VariantByName(name); err != nil || got != want {
+			t.Errorf("Test variant by name: got %v, %v for %q", got, err, name)
+		}
+	}
+	if _, err := VariantByName("atomic"); err == nil {
+		t.Error("Test variant by name: want error for unknown variant")
+	}
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	igame, err := InitGameVariant(generatePlayerIds(), KingOfTheHill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"e3", "e6", "Ke2", "Ke7", "Kd3", "Kd6"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.IsOver() {
+		t.Fatalf("Test king of the hill: game over with %s", igame.GetStatus())
+	}
+	if err := playSAN(igame, []string{"Ke4"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "WHITE_KING_OF_THE_HILL" || igame.Result() != "1-0" {
+		t.Errorf("Test king of the hill: got status %s, result %s", igame.GetStatus(), igame.Result())
+	}
+
+	pgn := igame.PGN(PGNTags{})
+	for _, want := range []string{`[Variant "King of the Hill"]`, "{White wins by bringing the king to the centre.} 1-0"} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("Test king of the hill: missing %q in\n%s", want, pgn)
+		}
+	}
+}
+
+func TestThreeCheck(t *testing.T) {
+	igame, err := initVariantGame(generatePlayerIds(), ThreeCheck, "4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"Ra8+", "Kd7", "Ra7+", "Kd6"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.IsOver() {
+		t.Fatalf("Test three check: game over with %s", igame.GetStatus())
+	}
+	if err := playSAN(igame, []string{"Ra6+"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "WHITE_THREE_CHECK" || igame.Result() != "1-0" {
+		t.Errorf("Test three check: got status %s, result %s", igame.GetStatus(), igame.Result())
+	}
+
+	// the check taken back no longer counts
+	if err := igame.UndoMove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"Rb7"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.IsOver() || igame.checks[0] != 2 {
+		t.Errorf("Test three check: got status %s with %d checks", igame.GetStatus(), igame.checks[0])
+	}
+}
+
+func TestVariantMaterial(t *testing.T) {
+	for _, tc := range []struct {
+		variant Variant
+		fen     string
+		move    string
+		want    string
+	}{
+		{Standard, "4k3/8/8/8/8/8/3p4/4K3 w - - 0 1", "Kxd2", "INSUFFICIENT_MATERIAL"},
+		// a lone king can still reach the centre
+		{KingOfTheHill, "4k3/8/8/8/8/8/3p4/4K3 w - - 0 1", "Kxd2", "ACTIVE"},
+		// the knight can still give checks
+		{ThreeCheck, "4k3/8/8/8/8/8/3p4/4K1N1 w - - 0 1", "Kxd2", "ACTIVE"},
+		{ThreeCheck, "4k3/8/8/8/8/8/3p4/4K3 w - - 0 1", "Kxd2", "INSUFFICIENT_MATERIAL"},
+	} {
+		igame, err := initVariantGame(generatePlayerIds(), tc.variant, tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := playSAN(igame, []string{tc.move}); err != nil {
+			t.Fatal(err)
+		}
+		if igame.GetStatus() != tc.want {
+			t.Errorf("Test variant material: %s %s, got status %s, want %s", tc.variant.Title(), tc.fen, igame.GetStatus(), tc.want)
+		}
+	}
+}
+
+func TestAntichess(t *testing.T) {
+	igame, err := InitGameVariant(generatePlayerIds(), Antichess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if igame.FEN() != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1" {
+		t.Errorf("Test antichess: got start fen %s", igame.FEN())
+	}
+
+	// captures are compulsory
+	if err := playSAN(igame, []string{"e4", "b5"}); err != nil {
+		t.Fatal(err)
+	}
+	if moves := igame.LegalMoves(); strings.Join(moves, " ") != "f1-b5" {
+		t.Errorf("Test antichess: got legal moves %v, want the capture only", moves)
+	}
+	p1, _ := igame.GetPlayerIds()
+	if err := igame.MakeMove(p1, "d2", "d4"); err == nil {
+		t.Error("Test antichess: want error for not capturing")
+	}
+
+	// the king can be left in check and taken
+	kings, err := initVariantGame(generatePlayerIds(), Antichess, "8/8/8/8/8/8/k7/K7 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(kings, []string{"Kxa2"}); err != nil {
+		t.Fatal(err)
+	}
+	if kings.GetStatus() != "BLACK_OUT_OF_PIECES" || kings.Result() != "0-1" {
+		t.Errorf("Test antichess: got status %s, result %s", kings.GetStatus(), kings.Result())
+	}
+}
+
+func TestAntichessPromotion(t *testing.T) {
+	igame, err := initVariantGame(generatePlayerIds(), Antichess, "8/P6p/8/8/8/8/8/8 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moves := igame.LegalMoves(); len(moves) != 5 {
+		t.Errorf("Test antichess promotion: got legal moves %v, want 5 promotions", moves)
+	}
+	if err := playSAN(igame, []string{"a8=K"}); err != nil {
+		t.Fatal(err)
+	}
+	if fen := igame.FEN(); fen != "K7/7p/8/8/8/8/8/8 b - - 0 1" {
+		t.Errorf("Test antichess promotion: got %s", fen)
+	}
+
+	// a standard game has no king promotion
+	standard, err := InitGameFromFEN(generatePlayerIds(), "7k/P7/8/8/8/8/8/7K w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, _ := standard.GetPlayerIds()
+	if err := standard.MakeMove(p1, "a7", "a8k"); err == nil {
+		t.Error("Test antichess promotion: want error for king promotion in a standard game")
+	}
+}
+
+func TestAntichessStalemated(t *testing.T) {
+	// black's pawn is blocked, so black has no move and wins
+	igame, err := initVariantGame(generatePlayerIds(), Antichess, "8/8/8/8/8/p7/8/2N5 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"Na2"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.GetStatus() != "BLACK_STALEMATED" {
+		t.Fatalf("Test antichess stalemated: got status %s", igame.GetStatus())
+	}
+
+	restored, err := RestoreGame(generatePlayerIds(), "antichess", igame.StartFEN(), igame.GetAllMoves(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetStatus() != igame.GetStatus() {
+		t.Errorf("Test antichess stalemated: got status %s after restore", restored.GetStatus())
+	}
+}
+
+func TestCrazyhouse(t *testing.T) {
+	igame, err := InitGameVariant(generatePlayerIds(), Crazyhouse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"e4", "d5", "exd5", "Qxd5", "Nc3"}); err != nil {
+		t.Fatal(err)
+	}
+	if fen := igame.FEN(); fen != "rnb1kbnr/ppp1pppp/8/3q4/8/2N5/PPPP1PPP/R1BQKBNR[Pp] b KQkq - 1 3" {
+		t.Errorf("Test crazyhouse: got %s", fen)
+	}
+
+	// the captured pawn is dropped back with black's colour
+	p1, p2 := igame.GetPlayerIds()
+	if err := igame.MakeMove(p2, "P@", "d2"); err == nil {
+		t.Error("Test crazyhouse: want error for drop on an occupied square")
+	}
+	if err := igame.MakeMove(p2, "N@", "f3"); err == nil {
+		t.Error("Test crazyhouse: want error for drop of a piece not in pocket")
+	}
+	if err := playSAN(igame, []string{"P@e3"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.Pocket(false) != "" || igame.board.boxes[4][2].piece.toFEN() != "p" {
+		t.Errorf("Test crazyhouse: got black pocket %q after drop", igame.Pocket(false))
+	}
+	if err := igame.MakeMove(p1, "P@", "e8"); err == nil {
+		t.Error("Test crazyhouse: want error for pawn drop on the last rank")
+	}
+
+	if err := igame.UndoMove(); err != nil {
+		t.Fatal(err)
+	}
+	if igame.Pocket(false) != "p" || igame.board.boxes[4][2].piece != nil {
+		t.Errorf("Test crazyhouse: got black pocket %q after undo", igame.Pocket(false))
+	}
+	if err := playSAN(igame, []string{"P@e3", "dxe3", "Qxd1+", "Kxd1"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.Pocket(true) != "QPP" || igame.Pocket(false) != "q" {
+		t.Errorf("Test crazyhouse: got pockets %q and %q", igame.Pocket(true), igame.Pocket(false))
+	}
+
+	moves := igame.GetAllMoves()
+	if moves[5] != "P@e3" || igame.GetAllMovesSAN()[5] != "P@e3" {
+		t.Errorf("Test crazyhouse: got drop %s", moves[5])
+	}
+	restored, err := RestoreGame(generatePlayerIds(), "crazyhouse", "", moves, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.FEN() != igame.FEN() {
+		t.Errorf("Test crazyhouse: got %s after restore, want %s", restored.FEN(), igame.FEN())
+	}
+}
+
+func TestCrazyhousePromotedCapture(t *testing.T) {
+	igame, err := initVariantGame(generatePlayerIds(), Crazyhouse, "4k3/P7/8/8/8/8/7K/r7[] w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"a8=Q+"}); err != nil {
+		t.Fatal(err)
+	}
+	if fen := igame.FEN(); fen != "Q~3k3/8/8/8/8/8/7K/r7[] b - - 0 1" {
+		t.Errorf("Test crazyhouse promoted capture: got %s", fen)
+	}
+
+	// the bitboard position pockets the promoted queen as a pawn too
+	pos := igame.position()
+	next := pos.play(posMove{from: uint8(squareIndex(0, 0)), to: uint8(squareIndex(0, 7))})
+	if next.pockets[1] != [kindKing]int{1, 0, 0, 0, 0} {
+		t.Errorf("Test crazyhouse promoted capture: got position pocket %v", next.pockets[1])
+	}
+
+	if err := playSAN(igame, []string{"Rxa8"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.Pocket(false) != "p" {
+		t.Errorf("Test crazyhouse promoted capture: got black pocket %q", igame.Pocket(false))
+	}
+
+	// the promoted mark is read back from the FEN
+	restored, err := initVariantGame(generatePlayerIds(), Crazyhouse, "Q~3k3/8/8/8/8/8/7K/r7[] b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(restored, []string{"Rxa8"}); err != nil {
+		t.Fatal(err)
+	}
+	if fen := restored.FEN(); fen != "r3k3/8/8/8/8/8/7K/8[p] w - - 0 2" {
+		t.Errorf("Test crazyhouse promoted capture: got %s after restore", fen)
+	}
+	if _, err := initVariantGame(generatePlayerIds(), Crazyhouse, "~4k3/8/8/8/8/8/7K/r7[] b - - 0 1"); err == nil {
+		t.Error("Test crazyhouse promoted capture: want error for promoted mark without piece")
+	}
+}
+
+func TestCrazyhouseDropBlock(t *testing.T) {
+	// the back rank check is blocked by a knight drop, a pawn can't be dropped on the first rank
+	igame, err := initVariantGame(generatePlayerIds(), Crazyhouse, "r3k3/8/8/8/8/8/5PPP/6K1[NP] b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(igame, []string{"Ra1+"}); err != nil {
+		t.Fatal(err)
+	}
+	if igame.IsOver() {
+		t.Fatalf("Test crazyhouse drop block: game over with %s", igame.GetStatus())
+	}
+	if got := strings.Join(igame.LegalMoves(), " "); got != "N@b1 N@c1 N@d1 N@e1 N@f1" {
+		t.Errorf("Test crazyhouse drop block: got legal moves %s", got)
+	}
+	p1, _ := igame.GetPlayerIds()
+	if err := igame.MakeMove(p1, "N@", "a3"); err == nil {
+		t.Error("Test crazyhouse drop block: want error for drop not blocking the check")
+	}
+
+	// without a piece in hand it is mate
+	mate, err := initVariantGame(generatePlayerIds(), Crazyhouse, "r3k3/8/8/8/8/8/5PPP/6K1[] b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := playSAN(mate, []string{"Ra1#"}); err != nil {
+		t.Fatal(err)
+	}
+	if mate.Result() != "0-1" {
+		t.Errorf("Test crazyhouse drop block: got status %s", mate.GetStatus())
+	}
+
+	if _, err := InitGameFromFEN(generatePlayerIds(), "r3k3/8/8/8/8/8/5PPP/6K1[N] b - - 0 1"); err == nil {
+		t.Error("Test crazyhouse drop block: want error for pieces in hand in standard chess")
+	}
+}
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// order of pieces in the zobrist piece table, indexed by FEN letter
+const zobristPieceOrder = "PNBRQKpnbrqk"
+
+var (
+	zobristPieces    [12][64]uint64
+	zobristSide      uint64
+	zobristCastling  [4]uint64
+	zobristEnpassant [8]uint64
+	zobristPockets   [2][kindKing][32]uint64 // by color, piece kind and number of pieces in hand
+)
+
+func init() {
+	// fixed seed so position hashes are stable across restarts
+	r := rand.New(rand.NewSource(20240628))
+	for i := range zobristPieces {
+		for j := range zobristPieces[i] {
+			zobristPieces[i][j] = r.Uint64()
+		}
+	}
+	zobristSide = r.Uint64()
+	for i := range zobristCastling {
+		zobristCastling[i] = r.Uint64()
+	}
+	for i := range zobristEnpassant {
+		zobristEnpassant[i] = r.Uint64()
+	}
+	for i := range zobristPockets {
+		for j := range zobristPockets[i] {
+			for k := range zobristPockets[i][j] {
+				zobristPockets[i][j][k] = r.Uint64()
+			}
+		}
+	}
+}
+
+/*
+Return the zobrist hash of the current position. Repetitions of a position
+share its hash, whatever moves led to them
+*/
+func (g *Game) Hash() uint64 {
+	return g.hash
+}
+
+func zobristPiece(p piece, x, y int) uint64 {
+	return zobristPieces[strings.Index(zobristPieceOrder, p.toFEN())][y*8+x]
+}
+
+// castling rights as a bit set in the order K, Q, k, q
+func (g *Game) castlingMask() int {
+	mask := 0
+	for i, rookX := range g.castlingRooks {
+		y := 7 * (i / 2)
+		white := y == 0
+		r, ok := g.board.boxes[rookX][y].piece.(*rook)
+		if !ok || r.isWhite() != white || r.initMoved {
+			continue
+		}
+		kingSpot := g.kingSpots[i/2]
+		if kingSpot == nil || kingSpot.y != y {
+			continue
+		}
+		if k, ok := kingSpot.piece.(*king); !ok || k.initMoved {
+			continue
+		}
+		mask |= 1 << i
+	}
+	return mask
+}
+
+// file of the en passant target if the side to move has a pawn to capture with, -1 otherwise
+func (g *Game) enpassantFile() int {
+	target := g.enpassantTarget()
+	if target == "-" {
+		return -1
+	}
+	x, _ := mapChessPosToCoord(target)
+	lastMove := g.GetLastMove()
+	for _, dx := range []int{-1, 1} {
+		if x+dx < 0 || x+dx > 7 {
+			continue
+		}
+		if p, ok := g.board.boxes[x+dx][lastMove.end.y].piece.(*pawn); ok && p.isWhite() == g.isWhiteTurn {
+			return x
+		}
+	}
+	return -1
+}
+
+func (g *Game) computeHash() uint64 {
+	var hash uint64
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			if p := g.board.boxes[x][y].piece; p != nil {
+				hash ^= zobristPiece(p, x, y)
+			}
+		}
+	}
+	if !g.isWhiteTurn {
+		hash ^= zobristSide
+	}
+	hash ^= castlingHash(g.castlingMask())
+	if file := g.enpassantFile(); file >= 0 {
+		hash ^= zobristEnpassant[file]
+	}
+	hash ^= pocketsHash(g.pockets)
+	return hash
+}
+
+// hash of the position, the same as the game gives it
+func (pos *position) hash() uint64 {
+	var hash uint64
+	for color := range pos.pieces {
+		for kind, b := range pos.pieces[color] {
+			for ; b != 0; b &= b - 1 {
+				hash ^= zobristPieces[color*6+kind][b.first()]
+			}
+		}
+	}
+	if !pos.whiteToMove {
+		hash ^= zobristSide
+	}
+	hash ^= castlingHash(pos.castling)
+	// the en passant file only counts if the side to move has a pawn to capture with
+	us := pos.side()
+	if pos.enpassant >= 0 && pawnAttacks[1-us][pos.enpassant]&pos.pieces[us][kindPawn] != 0 {
+		hash ^= zobristEnpassant[pos.enpassant%8]
+	}
+	hash ^= pocketsHash(pos.pockets)
+	return hash
+}
+
+// empty pockets hash to 0, so positions without pieces in hand hash as before
+func pocketsHash(pockets [2][kindKing]int) uint64 {
+	var hash uint64
+	for color := range pockets {
+		for kind, n := range pockets[color] {
+			if n > 0 {
+				hash ^= zobristPockets[color][kind][n]
+			}
+		}
+	}
+	return hash
+}
+
+func castlingHash(mask int) uint64 {
+	var hash uint64
+	for i := range zobristCastling {
+		if mask&(1<<i) != 0 {
+			hash ^= zobristCastling[i]
+		}
+	}
+	return hash
+}
+
+// pieces standing on the spots a move can change, taken before the board is updated
+type spotSnapshot struct {
+	spot  *spot
+	piece piece
+}
+
+func (g *Game) snapshotMoveSpots(move *move) []spotSnapshot {
+	if move.isDrop {
+		return []spotSnapshot{{spot: move.end, piece: move.end.piece}}
+	}
+	spots := []*spot{move.start, move.end}
+	switch move.pieceMoved.(type) {
+	case *pawn:
+		// the pawn taken en passant
+		spots = append(spots, g.board.boxes[move.end.x][move.start.y])
+	case *king:
+		// the rook and king squares of castling
+		for x := 0; x < 8; x++ {
+			spots = append(spots, g.board.boxes[x][move.start.y])
+		}
+	}
+
+	snapshots := make([]spotSnapshot, 0, len(spots))
+	for _, s := range spots {
+		snapshots = append(snapshots, spotSnapshot{spot: s, piece: s.piece})
+	}
+	return snapshots
+}
+
+// update the position hash incrementally from the state before the move
+func (g *Game) updateHash(snapshots []spotSnapshot, prevCastling, prevEnpassant int, prevPockets [2][kindKing]int) {
+	seen := map[*spot]bool{}
+	for _, snap := range snapshots {
+		if seen[snap.spot] || snap.piece == snap.spot.piece {
+			continue
+		}
+		seen[snap.spot] = true
+		if snap.piece != nil {
+			g.hash ^= zobristPiece(snap.piece, snap.spot.x, snap.spot.y)
+		}
+		if snap.spot.piece != nil {
+			g.hash ^= zobristPiece(snap.spot.piece, snap.spot.x, snap.spot.y)
+		}
+	}
+
+	g.hash ^= zobristSide
+	g.hash ^= castlingHash(prevCastling ^ g.castlingMask())
+	if prevEnpassant >= 0 {
+		g.hash ^= zobristEnpassant[prevEnpassant]
+	}
+	if file := g.enpassantFile(); file >= 0 {
+		g.hash ^= zobristEnpassant[file]
+	}
+	g.hash ^= pocketsHash(prevPockets) ^ pocketsHash(g.pockets)
+}
@@ -2,7 +2,9 @@ package agent
 
 import (
 	"github.com/gorilla/websocket"
+	"github.com/yelaco/go-chess-server/internal/analysis"
 	"github.com/yelaco/go-chess-server/internal/database"
+	"github.com/yelaco/go-chess-server/internal/game"
 	"github.com/yelaco/go-chess-server/pkg/corenet"
 	"github.com/yelaco/go-chess-server/pkg/logging"
 	"github.com/yelaco/go-chess-server/pkg/matcher"
@@ -14,13 +16,18 @@ import (
 type Agent struct {
 	wsServer *corenet.WebSocketServer
 	matcher  *matcher.Matcher
+	analyses *analysis.Queue
 }
 
+// finished matches waiting for analysis before new ones are turned away
+const analysisQueueSize = 100
+
 // Return an Agent object which is the center module interacting with other modules
 func NewAgent() *Agent {
 	a := &Agent{
 		wsServer: corenet.NewWebSocketServer(),
 		matcher:  matcher.NewMatcher(),
+		analyses: analysis.NewQueue(newEvaluator(), analysisQueueSize),
 	}
 	a.wsServer.SetMessageHandler(a.handleWebSocketMessage)
 	a.wsServer.SetConnCloseGameHandler(a.playerDisconnectHandler)
@@ -45,10 +52,18 @@ This includes saving the session to the database, close the session
 and remove session from tracking of Matcher
 */
 func (a *Agent) handleSessionGameOver(s *session.GameSession, sessionID string) {
-	playerIDs := make([]string, 0, 2)
-	for id, player := range s.Players {
-		playerIDs = append(playerIDs, id)
-		player.Conn.WriteJSON(struct {
+	// player ids in white, black order so the result is stored with the right sides
+	whiteID, blackID := s.Game.GetPlayerIds()
+	// spectators get the end of the game like the players
+	recipients := s.GetSpectators()
+	for _, player := range s.Players {
+		recipients = append(recipients, player)
+	}
+	for _, player := range recipients {
+		if player == nil || player.Bot != nil {
+			continue
+		}
+		player.WriteJSON(struct {
 			Type string            `json:"type"`
 			Data map[string]string `json:"data"`
 		}{
@@ -59,18 +74,47 @@ func (a *Agent) handleSessionGameOver(s *session.GameSession, sessionID string)
 		})
 		player.Conn.Close()
 	}
-	gameMoves := s.Game.GetAllMoves()
-	if _, err := database.InsertSession(sessionID, playerIDs[0], playerIDs[1], gameMoves); err != nil {
+	record := database.Session{
+		SessionID: sessionID,
+		Player1ID: whiteID,
+		Player2ID: blackID,
+		Moves:     s.Game.GetAllMoves(),
+		Status:    s.Game.GetStatus(),
+		Source:    database.SourceOnline,
+		Variant:   s.Game.Variant().Name(),
+	}
+	if s.Game.StartFEN() != game.StartingFEN {
+		record.StartFEN = s.Game.StartFEN()
+	}
+	if s.Clock != nil {
+		for _, remaining := range s.Game.MoveClocks() {
+			record.Clocks = append(record.Clocks, remaining.Milliseconds())
+		}
+	}
+	for _, message := range s.GetChat() {
+		record.Chat = append(record.Chat, database.ChatMessage(message))
+	}
+	if _, err := database.InsertSession(record); err != nil {
 		logging.Error("coulnd't save game", zap.Error(err))
+	} else if err := a.analyses.Enqueue(sessionID); err != nil {
+		logging.Warn("couldn't queue analysis",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
 	}
 	session.CloseSession(sessionID)
-	a.matcher.RemoveSession(playerIDs[0], playerIDs[1])
+	for _, player := range s.Players {
+		closeBot(player)
+	}
+	a.matcher.RemoveSession(whiteID, blackID)
 }
 
 /*
 Handler for when a user connection closes
 */
 func (a *Agent) playerDisconnectHandler(connID string) {
+	session.StopSpectating(connID)
+
 	playerID, ok := a.matcher.ConnMap[connID]
 	if !ok {
 		return
@@ -106,30 +150,204 @@ func (a *Agent) handleWebSocketMessage(conn *websocket.Conn, message *corenet.Me
 		Type  string `json:"type"`
 		Error string `json:"error"`
 	}
+
+	// game actions must come through the connection the player plays on, spectators only watch
+	switch message.Action {
+	case "move", "resign", "offer_draw", "accept_draw", "decline_draw", "claim_draw",
+		"takeback_request", "takeback_accept", "takeback_decline", "mute":
+		playerID, _ := message.Data["player_id"].(string)
+		sessionID, sessionOK := message.Data["session_id"].(string)
+		if sessionOK && !session.PlaysThrough(sessionID, playerID, conn) {
+			logging.Info("attempt game action",
+				zap.String("status", "rejected"),
+				zap.String("action", message.Action),
+				zap.String("player_id", playerID),
+				zap.String("session_id", sessionID),
+				zap.String("error", "not playing in the session"),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: "not playing in the session",
+			})
+			return
+		}
+	}
+
 	switch message.Action {
 	case "matching":
 		playerID, ok := message.Data["player_id"].(string)
-		if ok {
+		// players queue for untimed standard chess unless they ask for a variant or a time control
+		variantName, _ := message.Data["variant"].(string)
+		variant, err := game.VariantByName(variantName)
+		timeControl, _ := message.Data["time_control"].(string)
+		control, controlErr := session.ParseTimeControl(timeControl)
+		if err == nil {
+			err = controlErr
+		}
+		if ok && err != nil {
+			logging.Info("attempt matchmaking",
+				zap.String("status", "rejected"),
+				zap.String("error", err.Error()),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: err.Error(),
+			})
+		} else if ok {
 			*connID = utils.GenerateUUID()
 			logging.Info("attempt matchmaking",
 				zap.String("status", "queued"),
 				zap.String("player_id", playerID),
+				zap.String("variant", variant.Name()),
+				zap.String("time_control", control.String()),
 				zap.String("remote_address", conn.RemoteAddr().String()),
 			)
 			a.matcher.EnterQueue(&session.Player{
 				Conn: conn,
 				ID:   playerID,
-			}, *connID)
+			}, *connID, variant, control)
 		} else {
 			logging.Info("attempt matchmaking",
 				zap.String("status", "rejected"),
 				zap.String("error", "insufficient data"),
 				zap.String("remote_address", conn.RemoteAddr().String()),
 			)
-			conn.WriteJSON(errorResponse{
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: "insufficient data",
+			})
+		}
+	case "play_bot":
+		playerID, playerOK := message.Data["player_id"].(string)
+		level, levelOK := message.Data["level"].(float64)
+		if !playerOK || !levelOK {
+			logging.Info("attempt bot match",
+				zap.String("status", "rejected"),
+				zap.String("error", "insufficient data"),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: "insufficient data",
+			})
+			return
+		}
+		variantName, _ := message.Data["variant"].(string)
+		timeControl, _ := message.Data["time_control"].(string)
+		color, _ := message.Data["color"].(string)
+		engineName, _ := message.Data["engine"].(string)
+		variant, err := game.VariantByName(variantName)
+		control, controlErr := session.ParseTimeControl(timeControl)
+		if err == nil {
+			err = controlErr
+		}
+		// the bot is set up last, it may start an engine process
+		var bot *session.Player
+		botWhite := false
+		if err == nil {
+			bot, botWhite, err = newBotPlayer(int(level), color, engineName)
+		}
+		if err != nil {
+			logging.Info("attempt bot match",
+				zap.String("status", "rejected"),
+				zap.String("player_id", playerID),
+				zap.String("error", err.Error()),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: err.Error(),
+			})
+			return
+		}
+		*connID = utils.GenerateUUID()
+		logging.Info("attempt bot match",
+			zap.String("status", "accepted"),
+			zap.String("player_id", playerID),
+			zap.String("bot_id", bot.ID),
+			zap.String("variant", variant.Name()),
+			zap.String("time_control", control.String()),
+			zap.String("remote_address", conn.RemoteAddr().String()),
+		)
+		if !a.matcher.StartBotMatch(&session.Player{
+			Conn: conn,
+			ID:   playerID,
+		}, *connID, bot, botWhite, variant, control) {
+			closeBot(bot)
+		}
+	case "spectate":
+		playerID, playerOK := message.Data["player_id"].(string)
+		sessionID, sessionOK := message.Data["session_id"].(string)
+		if !playerOK || !sessionOK {
+			logging.Info("attempt spectate",
+				zap.String("status", "rejected"),
+				zap.String("error", "insufficient data"),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: "insufficient data",
+			})
+			return
+		}
+		if *connID == "" {
+			*connID = utils.GenerateUUID()
+		}
+		if err := session.Spectate(sessionID, *connID, &session.Player{
+			Conn: conn,
+			ID:   playerID,
+		}); err != nil {
+			logging.Info("attempt spectate",
+				zap.String("status", "rejected"),
+				zap.String("player_id", playerID),
+				zap.String("session_id", sessionID),
+				zap.String("error", err.Error()),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: err.Error(),
+			})
+		}
+	case "chat", "mute":
+		playerID, playerOK := message.Data["player_id"].(string)
+		sessionID, sessionOK := message.Data["session_id"].(string)
+		text, textOK := message.Data["message"].(string)
+		if !playerOK || !sessionOK || (message.Action == "chat" && !textOK) {
+			logging.Info("attempt chat",
+				zap.String("status", "rejected"),
+				zap.String("action", message.Action),
+				zap.String("error", "insufficient data"),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
 				Type:  "error",
 				Error: "insufficient data",
 			})
+			return
+		}
+
+		var err error
+		switch message.Action {
+		case "chat":
+			err = session.Chat(sessionID, playerID, conn, text)
+		case "mute":
+			err = session.MuteOpponent(sessionID, playerID)
+		}
+		if err != nil {
+			logging.Info("attempt chat",
+				zap.String("status", "rejected"),
+				zap.String("action", message.Action),
+				zap.String("player_id", playerID),
+				zap.String("session_id", sessionID),
+				zap.String("error", err.Error()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: err.Error(),
+			})
 		}
 	case "move":
 		playerID, playerOK := message.Data["player_id"].(string)
@@ -150,10 +368,117 @@ func (a *Agent) handleWebSocketMessage(conn *websocket.Conn, message *corenet.Me
 				zap.String("error", "insufficient data"),
 				zap.String("remote_address", conn.RemoteAddr().String()),
 			)
-			conn.WriteJSON(errorResponse{
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: "insufficient data",
+			})
+		}
+	case "resign":
+		playerID, playerOK := message.Data["player_id"].(string)
+		sessionID, sessionOK := message.Data["session_id"].(string)
+		if !playerOK || !sessionOK {
+			logging.Info("attempt resign",
+				zap.String("status", "rejected"),
+				zap.String("error", "insufficient data"),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: "insufficient data",
+			})
+			return
+		}
+		if err := session.Resign(sessionID, playerID); err != nil {
+			logging.Info("attempt resign",
+				zap.String("status", "rejected"),
+				zap.String("player_id", playerID),
+				zap.String("session_id", sessionID),
+				zap.String("error", err.Error()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: err.Error(),
+			})
+		}
+	case "offer_draw", "accept_draw", "decline_draw", "claim_draw":
+		playerID, playerOK := message.Data["player_id"].(string)
+		sessionID, sessionOK := message.Data["session_id"].(string)
+		if !playerOK || !sessionOK {
+			logging.Info("attempt draw",
+				zap.String("status", "rejected"),
+				zap.String("action", message.Action),
+				zap.String("error", "insufficient data"),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
 				Type:  "error",
 				Error: "insufficient data",
 			})
+			return
+		}
+
+		var err error
+		switch message.Action {
+		case "offer_draw":
+			err = session.OfferDraw(sessionID, playerID)
+		case "accept_draw":
+			err = session.AcceptDraw(sessionID, playerID)
+		case "decline_draw":
+			err = session.DeclineDraw(sessionID, playerID)
+		case "claim_draw":
+			err = session.ClaimDraw(sessionID, playerID)
+		}
+		if err != nil {
+			logging.Info("attempt draw",
+				zap.String("status", "rejected"),
+				zap.String("action", message.Action),
+				zap.String("player_id", playerID),
+				zap.String("session_id", sessionID),
+				zap.String("error", err.Error()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: err.Error(),
+			})
+		}
+	case "takeback_request", "takeback_accept", "takeback_decline":
+		playerID, playerOK := message.Data["player_id"].(string)
+		sessionID, sessionOK := message.Data["session_id"].(string)
+		if !playerOK || !sessionOK {
+			logging.Info("attempt takeback",
+				zap.String("status", "rejected"),
+				zap.String("action", message.Action),
+				zap.String("error", "insufficient data"),
+				zap.String("remote_address", conn.RemoteAddr().String()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: "insufficient data",
+			})
+			return
+		}
+
+		var err error
+		switch message.Action {
+		case "takeback_request":
+			err = session.RequestTakeback(sessionID, playerID)
+		case "takeback_accept":
+			err = session.AcceptTakeback(sessionID, playerID)
+		case "takeback_decline":
+			err = session.DeclineTakeback(sessionID, playerID)
+		}
+		if err != nil {
+			logging.Info("attempt takeback",
+				zap.String("status", "rejected"),
+				zap.String("action", message.Action),
+				zap.String("player_id", playerID),
+				zap.String("session_id", sessionID),
+				zap.String("error", err.Error()),
+			)
+			corenet.WriteJSON(conn, errorResponse{
+				Type:  "error",
+				Error: err.Error(),
+			})
 		}
 	default:
 	}
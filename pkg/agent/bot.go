@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+
+	"github.com/yelaco/go-chess-server/internal/analysis"
+	"github.com/yelaco/go-chess-server/internal/engine"
+	"github.com/yelaco/go-chess-server/pkg/config"
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"github.com/yelaco/go-chess-server/pkg/session"
+	"go.uber.org/zap"
+)
+
+/*
+Return a bot player searching at the engine level, and whether it plays white.
+The bot runs the built-in engine, or the external UCI engine of the config if
+asked for "uci". The human player picks the color "white", "black" or "random",
+white by default
+*/
+func newBotPlayer(level int, color, engineName string) (*session.Player, bool, error) {
+	botWhite := false
+	switch color {
+	case "", "white":
+	case "black":
+		botWhite = true
+	case "random":
+		botWhite = rand.Intn(2) == 0
+	default:
+		return nil, false, fmt.Errorf("invalid color: %s", color)
+	}
+
+	var bot session.Bot
+	switch engineName {
+	case "", "builtin":
+		e, err := engine.New(level)
+		if err != nil {
+			return nil, false, err
+		}
+		bot = e
+	case "uci":
+		if config.UCIEnginePath == "" {
+			return nil, false, errors.New("no uci engine configured")
+		}
+		if level < 1 || level > len(engine.Levels) {
+			return nil, false, fmt.Errorf("invalid engine level: %d, want 1 to %d", level, len(engine.Levels))
+		}
+		e, err := engine.NewUCIEngine(engine.UCIConfig{
+			Path:     config.UCIEnginePath,
+			MoveTime: config.UCIMoveTime,
+			// Stockfish plays from skill level 0 to 20
+			Options: map[string]string{"Skill Level": strconv.Itoa((level - 1) * 4)},
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		bot = e
+	default:
+		return nil, false, fmt.Errorf("invalid engine: %s", engineName)
+	}
+
+	return &session.Player{
+		ID:  fmt.Sprintf("bot-level-%d", level),
+		Bot: bot,
+	}, botWhite, nil
+}
+
+// stop the engine process of a bot running one
+func closeBot(player *session.Player) {
+	if player == nil {
+		return
+	}
+	if closer, ok := player.Bot.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// evaluator of the analysis of finished matches, the external UCI engine if configured
+func newEvaluator() analysis.Evaluator {
+	if config.UCIEnginePath != "" {
+		e, err := engine.NewUCIEngine(engine.UCIConfig{
+			Path:     config.UCIEnginePath,
+			MoveTime: config.UCIMoveTime,
+		})
+		if err == nil {
+			return e
+		}
+		logging.Warn("couldn't start uci engine for analysis, using the built-in engine",
+			zap.Error(err),
+		)
+	}
+	evaluator, err := analysis.NewBuiltinEvaluator()
+	if err != nil {
+		// the built-in engine level is fixed, it can't be invalid
+		panic(err)
+	}
+	return evaluator
+}
@@ -17,6 +17,12 @@ var (
 	DBHost          string
 	DBUser          string
 	DBPassword      string
+	UCIEnginePath   string        // external UCI engine, none if empty
+	UCIMoveTime     time.Duration // time the external engine thinks about a move
+	ChatMaxLength   int           // characters in a chat message
+	ChatRateLimit   int           // chat messages a user can send within the rate window
+	ChatRateWindow  time.Duration
+	ChatBannedWords []string // masked in chat messages
 )
 
 func init() {
@@ -40,4 +46,18 @@ func init() {
 	DBHost = viper.GetString("database.host")
 	DBUser = viper.GetString("database.user")
 	DBPassword = viper.GetString("database.password")
+
+	UCIEnginePath = viper.GetString("engine.uci_path")
+	UCIMoveTime = time.Duration(viper.GetInt("engine.uci_move_time")) * time.Millisecond
+	if UCIMoveTime <= 0 {
+		UCIMoveTime = time.Second
+	}
+
+	viper.SetDefault("chat.max_length", 200)
+	viper.SetDefault("chat.rate_limit", 5)
+	viper.SetDefault("chat.rate_window", 10)
+	ChatMaxLength = viper.GetInt("chat.max_length")
+	ChatRateLimit = viper.GetInt("chat.rate_limit")
+	ChatRateWindow = time.Duration(viper.GetInt("chat.rate_window")) * time.Second
+	ChatBannedWords = viper.GetStringSlice("chat.banned_words")
 }
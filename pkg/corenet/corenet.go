@@ -3,6 +3,7 @@ package corenet
 import (
 	"encoding/json"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/websocket"
 	"github.com/yelaco/go-chess-server/pkg/config"
@@ -22,6 +23,33 @@ type Message struct {
 	Data   map[string]interface{} `json:"data"`
 }
 
+// write lock of each open connection, gorilla/websocket allows one writer at a time
+var (
+	writeLocks   = map[*websocket.Conn]*sync.Mutex{}
+	writeLocksMu sync.Mutex
+)
+
+/*
+Write the message as JSON to the connection, waiting for any other write to it,
+e.g. from a clock or a bot of the session, to finish first
+*/
+func WriteJSON(conn *websocket.Conn, v any) error {
+	lock := writeLock(conn)
+	lock.Lock()
+	defer lock.Unlock()
+	return conn.WriteJSON(v)
+}
+
+// lock of an open connection, a closed one gets a new lock as its writes fail anyway
+func writeLock(conn *websocket.Conn) *sync.Mutex {
+	writeLocksMu.Lock()
+	defer writeLocksMu.Unlock()
+	if lock, ok := writeLocks[conn]; ok {
+		return lock
+	}
+	return &sync.Mutex{}
+}
+
 func NewWebSocketServer() *WebSocketServer {
 	return &WebSocketServer{
 		address: "0.0.0.0:" + config.Port,
@@ -59,7 +87,15 @@ func (s *WebSocketServer) Start() error {
 			logging.Error("failed to upgrade connection", zap.String("error", err.Error()))
 			return
 		}
-		defer conn.Close()
+		writeLocksMu.Lock()
+		writeLocks[conn] = &sync.Mutex{}
+		writeLocksMu.Unlock()
+		defer func() {
+			conn.Close()
+			writeLocksMu.Lock()
+			delete(writeLocks, conn)
+			writeLocksMu.Unlock()
+		}()
 		var connID string
 		for {
 			_, message, err := conn.ReadMessage()
@@ -5,10 +5,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/yelaco/go-chess-server/internal/game"
 	"github.com/yelaco/go-chess-server/pkg/config"
 	"github.com/yelaco/go-chess-server/pkg/logging"
 	"github.com/yelaco/go-chess-server/pkg/session"
-	"github.com/yelaco/go-chess-server/pkg/utils"
 	"go.uber.org/zap"
 )
 
@@ -16,7 +16,7 @@ import (
 A Matcher handles matchmaking logic and forwards the player connection to session manager
 */
 type Matcher struct {
-	Queue      []*session.Player
+	Queues     map[string][]*session.Player // players waiting for a match, by variant name and time control
 	SessionMap map[string]string
 	ConnMap    map[string]string
 	mu         sync.Mutex
@@ -30,9 +30,14 @@ type matchResponse struct {
 }
 
 type gameStateResponse struct {
-	Status      string `json:"status,omitempty"`
-	BoardFen    string `json:"board_fen,omitempty"`
-	IsWhiteTurn bool   `json:"is_white_turn,omitempty"`
+	Variant     string           `json:"variant,omitempty"`
+	Status      string           `json:"status,omitempty"`
+	BoardFen    string           `json:"board_fen,omitempty"`
+	IsWhiteTurn bool             `json:"is_white_turn,omitempty"`
+	LegalMoves  []string         `json:"legal_moves,omitempty"`
+	Pockets     *session.Pockets `json:"pockets,omitempty"`
+	Clocks      *session.Clocks  `json:"clocks,omitempty"`
+	Spectators  int              `json:"spectators,omitempty"`
 }
 
 type timeoutResponpse struct {
@@ -45,21 +50,26 @@ Return a Matcher with initialized fields
 */
 func NewMatcher() *Matcher {
 	return &Matcher{
-		Queue:      []*session.Player{},
+		Queues:     map[string][]*session.Player{},
 		SessionMap: map[string]string{},
 		ConnMap:    map[string]string{},
 		mu:         sync.Mutex{},
 	}
 }
 
+// name of the queue of the players asking for the variant and time control, e.g. "standard 300+2"
+func queueName(variant game.Variant, control session.TimeControl) string {
+	return variant.Name() + " " + control.String()
+}
+
 /*
-Enter players to the matching queue. Matcher also keeps track of connection ID
+Enter players to the matching queue of the variant and time control. Matcher also keeps track of connection ID
 to ensure no user can enter queue multiple time at the same time.
 After timeout, Matcher will cancel queueing of the corresponding player
 if there aren't no matches available.
 The player can also rejoin an unfinished match they left
 */
-func (m *Matcher) EnterQueue(player *session.Player, connID string) {
+func (m *Matcher) EnterQueue(player *session.Player, connID string, variant game.Variant, control session.TimeControl) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	sessionID, exists := m.SessionMap[player.ID]
@@ -69,7 +79,7 @@ func (m *Matcher) EnterQueue(player *session.Player, connID string) {
 	}
 	for _, pid := range m.ConnMap {
 		if pid == player.ID {
-			player.Conn.WriteJSON(struct {
+			player.WriteJSON(struct {
 				Type  string `json:"type"`
 				Error string `json:"error"`
 			}{
@@ -79,22 +89,23 @@ func (m *Matcher) EnterQueue(player *session.Player, connID string) {
 			return
 		}
 	}
-	m.Queue = append(m.Queue, player)
+	queue := queueName(variant, control)
+	m.Queues[queue] = append(m.Queues[queue], player)
 	m.ConnMap[connID] = player.ID
-	go m.leaveQueueIfTimeout(player, connID)
-	go m.findMatch()
+	go m.leaveQueueIfTimeout(player, connID, queue)
+	go m.findMatch(variant, control)
 }
 
 /*
 Matcher pushes player out of the matching queue after a timeout if there aren't no matches available.
 */
-func (m *Matcher) leaveQueueIfTimeout(player *session.Player, connID string) {
+func (m *Matcher) leaveQueueIfTimeout(player *session.Player, connID, queueName string) {
 	time.Sleep(config.MatchingTimeout)
 	if player == nil {
 		return
 	}
 	if _, ok := m.SessionMap[player.ID]; !ok {
-		player.Conn.WriteJSON(timeoutResponpse{
+		player.WriteJSON(timeoutResponpse{
 			Type:    "timeout",
 			Message: "Canceled matching due to timeout",
 		})
@@ -104,9 +115,10 @@ func (m *Matcher) leaveQueueIfTimeout(player *session.Player, connID string) {
 	defer m.mu.Unlock()
 
 	delete(m.ConnMap, connID)
-	for i, p := range m.Queue {
+	queue := m.Queues[queueName]
+	for i, p := range queue {
 		if p.ID == player.ID || p == player {
-			m.Queue = append(m.Queue[:i], m.Queue[i+1:]...)
+			m.Queues[queueName] = append(queue[:i], queue[i+1:]...)
 			return
 		}
 	}
@@ -116,22 +128,32 @@ func generateSessionId() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
-func (m *Matcher) findMatch() {
+func (m *Matcher) findMatch(variant game.Variant, control session.TimeControl) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if len(m.Queue) >= 2 {
-		player1 := m.Queue[0]
-		player2 := m.Queue[1]
-		m.Queue = m.Queue[2:]
+	name := queueName(variant, control)
+	if queue := m.Queues[name]; len(queue) >= 2 {
+		player1 := queue[0]
+		player2 := queue[1]
+		m.Queues[name] = queue[2:]
 
 		sessionID := generateSessionId()
-		session.InitSession(sessionID, player1, player2)
+		if err := session.InitSession(sessionID, variant, control, player1, player2); err != nil {
+			logging.Error("couldn't init match",
+				zap.String("variant", variant.Name()),
+				zap.String("time_control", control.String()),
+				zap.Error(err),
+			)
+			return
+		}
 		m.SessionMap[player1.ID] = sessionID
 		m.SessionMap[player2.ID] = sessionID
 
 		logging.Info("init match",
 			zap.String("player_1", player1.ID),
 			zap.String("player_2", player2.ID),
+			zap.String("variant", variant.Name()),
+			zap.String("time_control", control.String()),
 		)
 
 		notifyMatchingResult(sessionID, player1)
@@ -139,9 +161,65 @@ func (m *Matcher) findMatch() {
 	}
 }
 
+/*
+Start a match between the player and a bot without queueing, the bot playing white if asked,
+and return whether it started. Like with EnterQueue, a player with an unfinished match
+rejoins it instead
+*/
+func (m *Matcher) StartBotMatch(player *session.Player, connID string, bot *session.Player, botWhite bool, variant game.Variant, control session.TimeControl) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessionID, exists := m.SessionMap[player.ID]
+	if exists {
+		m.rejoinMatch(sessionID, player)
+		return false
+	}
+	for _, pid := range m.ConnMap {
+		if pid == player.ID {
+			player.WriteJSON(struct {
+				Type  string `json:"type"`
+				Error string `json:"error"`
+			}{
+				Type:  "queueing",
+				Error: "Already queued",
+			})
+			return false
+		}
+	}
+
+	white, black := player, bot
+	if botWhite {
+		white, black = bot, player
+	}
+	sessionID = generateSessionId()
+	if err := session.InitSession(sessionID, variant, control, white, black); err != nil {
+		logging.Error("couldn't init bot match",
+			zap.String("variant", variant.Name()),
+			zap.String("time_control", control.String()),
+			zap.Error(err),
+		)
+		return false
+	}
+	// bots aren't tracked, they can play any number of matches at once
+	m.SessionMap[player.ID] = sessionID
+	m.ConnMap[connID] = player.ID
+
+	logging.Info("init bot match",
+		zap.String("player_1", white.ID),
+		zap.String("player_2", black.ID),
+		zap.String("variant", variant.Name()),
+		zap.String("time_control", control.String()),
+	)
+
+	notifyMatchingResult(sessionID, player)
+	// a bot playing white moves first
+	go session.PlayBotMove(sessionID)
+	return true
+}
+
 func (m *Matcher) rejoinMatch(sessionID string, player *session.Player) {
 	if err := session.PlayerJoin(sessionID, player); err != nil {
-		player.Conn.WriteJSON(struct {
+		player.WriteJSON(struct {
 			Type  string `json:"type"`
 			Error string `json:"error"`
 		}{
@@ -156,7 +234,7 @@ func (m *Matcher) rejoinMatch(sessionID string, player *session.Player) {
 func notifyMatchingResult(sessionID string, player *session.Player) {
 	gameState, err := session.GetGameState(sessionID)
 	if err != nil {
-		player.Conn.WriteJSON(struct {
+		player.WriteJSON(struct {
 			Type  string `json:"type"`
 			Error string `json:"error"`
 		}{
@@ -168,7 +246,7 @@ func notifyMatchingResult(sessionID string, player *session.Player) {
 
 	playerState, err := session.GetPlayerState(sessionID, player.ID)
 	if err != nil {
-		player.Conn.WriteJSON(struct {
+		player.WriteJSON(struct {
 			Type  string `json:"type"`
 			Error string `json:"error"`
 		}{
@@ -177,13 +255,18 @@ func notifyMatchingResult(sessionID string, player *session.Player) {
 		})
 	}
 
-	player.Conn.WriteJSON(matchResponse{
+	player.WriteJSON(matchResponse{
 		Type:      "matched",
 		SessionID: sessionID,
 		GameState: gameStateResponse{
+			Variant:     gameState.Variant,
 			Status:      gameState.Status,
-			BoardFen:    utils.BoardToFen(gameState.Board),
+			BoardFen:    gameState.Fen,
 			IsWhiteTurn: gameState.IsWhiteTurn,
+			LegalMoves:  gameState.LegalMoves,
+			Pockets:     gameState.Pockets,
+			Clocks:      gameState.Clocks,
+			Spectators:  gameState.Spectators,
 		},
 		PlayerState: playerState,
 	})
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"errors"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+/*
+ * Bot
+ * Computer opponent playing on the server. A bot player has a Bot and no connection
+ */
+type Bot interface {
+	// best move for the side to move, in the form of "e2-e4"
+	BestMove(g *game.Game) (string, error)
+}
+
+// bot searching a copy of the position, which is taken without replaying the game
+type positionBot interface {
+	BestMoveFrom(pos game.SearchPosition) (string, error)
+}
+
+var errBotOpponent = errors.New("opponent is a bot")
+
+// the player whose turn it is in the session
+func playerToMove(session *GameSession) *Player {
+	whiteID, blackID := session.Game.GetPlayerIds()
+	if session.Game.GetCurrentTurn() {
+		return session.Players[whiteID]
+	}
+	return session.Players[blackID]
+}
+
+/*
+Let the bot to move in the session think on a copy of the position, then play its move
+through ProcessFenMove like any player. Does nothing if it's not a bot's turn
+*/
+func PlayBotMove(sessionID string) {
+	mu.Lock()
+	session, exists := gameSessions[sessionID]
+	if !exists || session.Game.IsOver() {
+		mu.Unlock()
+		return
+	}
+	bot := playerToMove(session)
+	if bot == nil || bot.Bot == nil {
+		mu.Unlock()
+		return
+	}
+	fen := session.Game.FEN()
+	think, err := botSearch(bot.Bot, session.Game)
+	mu.Unlock()
+	if err != nil {
+		logging.Error("couldn't copy game for bot",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	move, err := think()
+	if err != nil {
+		logging.Error("bot found no move",
+			zap.String("session_id", sessionID),
+			zap.String("player_id", bot.ID),
+			zap.Error(err),
+		)
+		// resign rather than leave the opponent waiting
+		Resign(sessionID, bot.ID)
+		return
+	}
+	ProcessFenMove(sessionID, bot.ID, fen+" "+move)
+}
+
+// search of the bot on a copy of the game, taken while the session is locked
+func botSearch(bot Bot, g *game.Game) (func() (string, error), error) {
+	if b, ok := bot.(positionBot); ok {
+		pos := g.SearchPosition()
+		return func() (string, error) { return b.BestMoveFrom(pos) }, nil
+	}
+	// external engines are sent the moves of the whole game
+	clone, err := g.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return func() (string, error) { return bot.BestMove(clone) }, nil
+}
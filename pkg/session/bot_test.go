@@ -0,0 +1,64 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/yelaco/go-chess-server/internal/engine"
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+// bot playing the first legal move
+type firstMoveBot struct{}
+
+func (firstMoveBot) BestMove(g *game.Game) (string, error) {
+	return g.LegalMoves()[0], nil
+}
+
+func TestPlayBotMove(t *testing.T) {
+	sessionID := "bot-test"
+	bot := &Player{ID: "bot", Bot: firstMoveBot{}}
+	human := &Player{ID: "human"}
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, bot, human); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+
+	PlayBotMove(sessionID)
+	if moves := gameSessions[sessionID].Game.GetAllMoves(); len(moves) != 1 {
+		t.Fatalf("Test play bot move: got moves %v, want the bot's first move", moves)
+	}
+	// not the bot's turn anymore
+	PlayBotMove(sessionID)
+	if moves := gameSessions[sessionID].Game.GetAllMoves(); len(moves) != 1 {
+		t.Errorf("Test play bot move: got moves %v on the player's turn", moves)
+	}
+
+	if err := OfferDraw(sessionID, human.ID); err != errBotOpponent {
+		t.Errorf("Test play bot move: got %v offering a draw to the bot", err)
+	}
+	if err := RequestTakeback(sessionID, human.ID); err != errBotOpponent {
+		t.Errorf("Test play bot move: got %v asking the bot for a takeback", err)
+	}
+}
+
+func TestPlayBotMoveEngine(t *testing.T) {
+	sessionID := "bot-engine-test"
+	e, err := engine.New(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the engine searches a copy of the position instead of a replayed game
+	if _, ok := Bot(e).(positionBot); !ok {
+		t.Fatal("Test play bot move engine: engine doesn't search positions")
+	}
+	bot := &Player{ID: "bot", Bot: e}
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, bot, &Player{ID: "human"}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+
+	PlayBotMove(sessionID)
+	if moves := gameSessions[sessionID].Game.GetAllMoves(); len(moves) != 1 {
+		t.Errorf("Test play bot move engine: got moves %v, want the bot's move", moves)
+	}
+}
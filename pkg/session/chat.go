@@ -0,0 +1,214 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/yelaco/go-chess-server/pkg/config"
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// chat channels of a session, each only read by its members
+const (
+	PlayersChannel    = "players"
+	SpectatorsChannel = "spectators"
+)
+
+/*
+ * ChatMessage
+ * Message sent in the chat of a session, kept as sent for abuse reports
+ */
+type ChatMessage struct {
+	PlayerID string    `json:"player_id"`
+	Channel  string    `json:"channel"`
+	Text     string    `json:"text"`
+	Time     time.Time `json:"time"`
+}
+
+type chatResponse struct {
+	Type     string `json:"type"`
+	Channel  string `json:"channel"`
+	PlayerID string `json:"player_id"`
+	Text     string `json:"text"`
+}
+
+// matches the banned words of the config, nil if there are none
+var bannedWords = bannedWordsPattern(config.ChatBannedWords)
+
+func bannedWordsPattern(words []string) *regexp.Regexp {
+	quoted := []string{}
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// mask the banned words of the message with asterisks
+func filterChat(text string) string {
+	if bannedWords == nil {
+		return text
+	}
+	return bannedWords.ReplaceAllStringFunc(text, func(word string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+}
+
+/*
+Send a chat message from a player or a spectator of the session, through the connection
+they play or watch on. Players chat with each other and spectators among themselves
+*/
+func Chat(sessionID, playerID string, conn *websocket.Conn, text string) error {
+	mu.Lock()
+	response, recipients, err := postChat(sessionID, playerID, conn, text)
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// sent outside the lock, a slow connection mustn't hold up the other sessions
+	for _, recipient := range recipients {
+		if err := recipient.WriteJSON(response); err != nil {
+			logging.Info("ws write", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// record the message of the sender on the connection, return it with the connected
+// members of the channel to relay it to. Called under the lock
+func postChat(sessionID, playerID string, conn *websocket.Conn, text string) (chatResponse, []*Player, error) {
+	session, exists := gameSessions[sessionID]
+	if !exists {
+		return chatResponse{}, nil, errors.New("invalid session id")
+	}
+	if conn == nil {
+		return chatResponse{}, nil, errors.New("not in the session")
+	}
+
+	var sender *Player
+	channel := PlayersChannel
+	if player, ok := session.Players[playerID]; ok {
+		if player.connected() && player.Conn == conn {
+			sender = player
+		}
+	} else {
+		for _, spectator := range session.Spectators {
+			if spectator.ID == playerID && spectator.Conn == conn {
+				sender, channel = spectator, SpectatorsChannel
+			}
+		}
+	}
+	if sender == nil {
+		return chatResponse{}, nil, errors.New("not in the session")
+	}
+
+	response, err := recordChat(session, sender, channel, text)
+	if err != nil {
+		return chatResponse{}, nil, err
+	}
+	connected := []*Player{}
+	for _, recipient := range chatRecipients(session, sender, channel) {
+		if recipient.connected() {
+			connected = append(connected, recipient)
+		}
+	}
+	return response, connected, nil
+}
+
+/*
+Mute the opponent of the player in the chat for the rest of the game
+*/
+func MuteOpponent(sessionID, playerID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	session, err := activeSession(sessionID, playerID)
+	if err != nil {
+		return err
+	}
+	session.Muted[playerID] = true
+
+	logging.Info("opponent muted",
+		zap.String("session_id", sessionID),
+		zap.String("player_id", playerID),
+	)
+
+	return nil
+}
+
+/*
+Return the messages sent in the chat of the session
+*/
+func (s *GameSession) GetChat() []ChatMessage {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]ChatMessage{}, s.Chat...)
+}
+
+// check the message against the length and rate limits, then record it and return
+// it as relayed to the channel, with the banned words masked. Called under the lock
+func recordChat(session *GameSession, sender *Player, channel, text string) (chatResponse, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return chatResponse{}, errors.New("empty message")
+	}
+	if utf8.RuneCountInString(text) > config.ChatMaxLength {
+		return chatResponse{}, fmt.Errorf("message longer than %d characters", config.ChatMaxLength)
+	}
+
+	sentAt := now()
+	recent := []time.Time{}
+	for _, t := range session.chatTimes[sender.ID] {
+		if sentAt.Sub(t) < config.ChatRateWindow {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= config.ChatRateLimit {
+		session.chatTimes[sender.ID] = recent
+		return chatResponse{}, errors.New("too many messages, slow down")
+	}
+	session.chatTimes[sender.ID] = append(recent, sentAt)
+
+	session.Chat = append(session.Chat, ChatMessage{
+		PlayerID: sender.ID,
+		Channel:  channel,
+		Text:     text,
+		Time:     sentAt,
+	})
+
+	return chatResponse{
+		Type:     "chat",
+		Channel:  channel,
+		PlayerID: sender.ID,
+		Text:     filterChat(text),
+	}, nil
+}
+
+// members of the channel who get the message, leaving out players who muted the sender
+func chatRecipients(session *GameSession, sender *Player, channel string) []*Player {
+	recipients := []*Player{}
+	if channel == SpectatorsChannel {
+		for _, spectator := range session.Spectators {
+			recipients = append(recipients, spectator)
+		}
+		return recipients
+	}
+	for id, player := range session.Players {
+		if player == nil || (id != sender.ID && session.Muted[id]) {
+			continue
+		}
+		recipients = append(recipients, player)
+	}
+	return recipients
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+	"github.com/yelaco/go-chess-server/pkg/config"
+)
+
+func TestChat(t *testing.T) {
+	current := time.Unix(0, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	sessionID := "chat-test"
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, &Player{ID: "white"}, &Player{ID: "black"}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+	session := gameSessions[sessionID]
+	white, black := session.Players["white"], session.Players["black"]
+
+	if _, err := recordChat(session, white, PlayersChannel, "  good luck "); err != nil {
+		t.Fatal(err)
+	}
+	if chat := session.GetChat(); len(chat) != 1 || chat[0].Text != "good luck" || chat[0].Channel != PlayersChannel {
+		t.Errorf("Test chat: got history %+v", chat)
+	}
+	for _, invalid := range []string{" ", strings.Repeat("a", config.ChatMaxLength+1)} {
+		if _, err := recordChat(session, white, PlayersChannel, invalid); err == nil {
+			t.Errorf("Test chat: want error for message of length %d", len(invalid))
+		}
+	}
+
+	// the rate limit applies to each user within the window
+	for i := 1; i < config.ChatRateLimit; i++ {
+		if _, err := recordChat(session, white, PlayersChannel, "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := recordChat(session, white, PlayersChannel, "hi"); err == nil {
+		t.Error("Test chat: want error over the rate limit")
+	}
+	if _, err := recordChat(session, black, PlayersChannel, "hi"); err != nil {
+		t.Errorf("Test chat: got %v for the opponent", err)
+	}
+	current = current.Add(config.ChatRateWindow)
+	if _, err := recordChat(session, white, PlayersChannel, "hi"); err != nil {
+		t.Errorf("Test chat: got %v after the rate window", err)
+	}
+
+	if err := MuteOpponent(sessionID, "black"); err != nil {
+		t.Fatal(err)
+	}
+	if recipients := chatRecipients(session, white, PlayersChannel); len(recipients) != 1 || recipients[0] != white {
+		t.Errorf("Test chat: got %d recipients for a muted player", len(recipients))
+	}
+	if recipients := chatRecipients(session, black, PlayersChannel); len(recipients) != 2 {
+		t.Errorf("Test chat: got %d recipients for the player who muted", len(recipients))
+	}
+
+	if err := Spectate(sessionID, "conn-1", &Player{ID: "coach"}); err != nil {
+		t.Fatal(err)
+	}
+	coach := session.Spectators["conn-1"]
+	if recipients := chatRecipients(session, coach, SpectatorsChannel); len(recipients) != 1 || recipients[0] != coach {
+		t.Errorf("Test chat: got %d recipients in the spectators channel", len(recipients))
+	}
+	// messages go through the connection of the sender
+	if err := Chat(sessionID, "white", nil, "hi"); err == nil {
+		t.Error("Test chat: want error without connection")
+	}
+}
+
+func TestFilterChat(t *testing.T) {
+	defer func(pattern *regexp.Regexp) { bannedWords = pattern }(bannedWords)
+	bannedWords = bannedWordsPattern([]string{"darn", " ", "h*ck"})
+	if got := filterChat("Darn it, darning h*ck"); got != "**** it, darning ****" {
+		t.Errorf("Test filter chat: got %q", got)
+	}
+	bannedWords = bannedWordsPattern(nil)
+	if got := filterChat("darn"); got != "darn" {
+		t.Errorf("Test filter chat: got %q without banned words", got)
+	}
+}
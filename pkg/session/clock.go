@@ -0,0 +1,177 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+/*
+ * TimeControl
+ * Time each player starts with and the time given after each move,
+ * either as a Fischer increment or as a Bronstein delay
+ */
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration // added to the clock after each move
+	Delay     time.Duration // time used up to the delay is given back after each move
+}
+
+var timeControlPattern = regexp.MustCompile(`^(\d+)(?:\+(\d+))?(?:d(\d+))?$`)
+
+/*
+Parse a time control given in seconds, e.g. "300+2" for 5 minutes with a 2 second
+increment or "300d2" for 5 minutes with a 2 second delay. An empty string or "-"
+stands for a game without clocks
+*/
+func ParseTimeControl(s string) (TimeControl, error) {
+	if s == "" || s == "-" {
+		return TimeControl{}, nil
+	}
+	matches := timeControlPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return TimeControl{}, fmt.Errorf("invalid time control: %s", s)
+	}
+
+	seconds := [3]time.Duration{}
+	for i, match := range matches[1:] {
+		if match == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match)
+		if err != nil {
+			return TimeControl{}, fmt.Errorf("invalid time control: %s", s)
+		}
+		seconds[i] = time.Duration(n) * time.Second
+	}
+	if seconds[0] == 0 {
+		return TimeControl{}, errors.New("invalid time control: no base time")
+	}
+	return TimeControl{Base: seconds[0], Increment: seconds[1], Delay: seconds[2]}, nil
+}
+
+/*
+Return the time control in the form taken by ParseTimeControl, "-" without clocks
+*/
+func (tc TimeControl) String() string {
+	if tc.Base == 0 {
+		return "-"
+	}
+	s := strconv.Itoa(int(tc.Base / time.Second))
+	if tc.Increment > 0 {
+		s += "+" + strconv.Itoa(int(tc.Increment/time.Second))
+	}
+	if tc.Delay > 0 {
+		s += "d" + strconv.Itoa(int(tc.Delay/time.Second))
+	}
+	return s
+}
+
+/*
+ * Clock
+ * Chess clock of a session. The server keeps the time of both players,
+ * only the clock of the side to move runs
+ */
+type Clock struct {
+	control   TimeControl
+	remaining [2]time.Duration // time left to white and black when their clock was last stopped
+	running   int              // side whose clock runs, -1 while stopped
+	since     time.Time        // when the running clock was started
+	timer     *time.Timer
+	onFlag    func()
+}
+
+// current time, replaced in tests
+var now = time.Now
+
+func newClock(control TimeControl, onFlag func()) *Clock {
+	return &Clock{
+		control:   control,
+		remaining: [2]time.Duration{control.Base, control.Base},
+		running:   -1,
+		onFlag:    onFlag,
+	}
+}
+
+func sideIndex(white bool) int {
+	if white {
+		return 0
+	}
+	return 1
+}
+
+// start the clock of the given side, its flag falls once its time is used up
+func (c *Clock) start(white bool) {
+	c.stop()
+	c.running = sideIndex(white)
+	c.since = now()
+	c.timer = time.AfterFunc(c.remaining[c.running], c.onFlag)
+}
+
+// stop the running clock and return the time the side used
+func (c *Clock) stop() time.Duration {
+	if c.running < 0 {
+		return 0
+	}
+	c.timer.Stop()
+	used := now().Sub(c.since)
+	c.remaining[c.running] -= used
+	c.running = -1
+	return used
+}
+
+// stop the clock of the side that moved, giving back the delay and adding the increment,
+// and return the time it has left
+func (c *Clock) punch() time.Duration {
+	side := c.running
+	if side < 0 {
+		return 0
+	}
+	c.remaining[side] += min(c.stop(), c.control.Delay) + c.control.Increment
+	return c.remaining[side]
+}
+
+// check if the time of the running side is used up
+func (c *Clock) flagged() bool {
+	return c.running >= 0 && c.Remaining(c.running == 0) <= 0
+}
+
+/*
+Return the time left to the given side, counting down while its clock runs
+*/
+func (c *Clock) Remaining(white bool) time.Duration {
+	side := sideIndex(white)
+	remaining := c.remaining[side]
+	if c.running == side {
+		remaining -= now().Sub(c.since)
+	}
+	return max(remaining, 0)
+}
+
+// end the game of the session on time once the side to move has used up its time
+func flagFall(sessionID string) {
+	mu.Lock()
+	session, exists := gameSessions[sessionID]
+	if !exists || session.Clock == nil || session.Game.IsOver() || !session.Clock.flagged() {
+		mu.Unlock()
+		return
+	}
+	session.Clock.stop()
+	if err := session.Game.Timeout(session.Game.GetCurrentTurn()); err != nil {
+		mu.Unlock()
+		return
+	}
+	logging.Info("flag fall",
+		zap.String("session_id", sessionID),
+		zap.String("status", session.Game.GetStatus()),
+	)
+	mu.Unlock()
+
+	notifyGameState(sessionID, session)
+	gameOverHandler(session, sessionID)
+}
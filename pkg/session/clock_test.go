@@ -0,0 +1,65 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	for s, want := range map[string]TimeControl{
+		"":       {},
+		"300":    {Base: 5 * time.Minute},
+		"300+2":  {Base: 5 * time.Minute, Increment: 2 * time.Second},
+		"180d3":  {Base: 3 * time.Minute, Delay: 3 * time.Second},
+		"60+1d2": {Base: time.Minute, Increment: time.Second, Delay: 2 * time.Second},
+	} {
+		got, err := ParseTimeControl(s)
+		if err != nil || got != want {
+			t.Errorf("Test parse time control: got %v, %v for %q", got, err, s)
+		}
+		if s != "" && got.String() != s {
+			t.Errorf("Test parse time control: got %s, want %s", got.String(), s)
+		}
+	}
+	for _, invalid := range []string{"5m", "0+2", "300+", "+2"} {
+		if _, err := ParseTimeControl(invalid); err == nil {
+			t.Errorf("Test parse time control: want error for %q", invalid)
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	current := time.Unix(0, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	clock := newClock(TimeControl{Base: time.Minute, Increment: 2 * time.Second}, func() {})
+	clock.start(true)
+	current = current.Add(10 * time.Second)
+	if got := clock.Remaining(true); got != 50*time.Second {
+		t.Errorf("Test clock: got %v left while running, want 50s", got)
+	}
+	if got := clock.punch(); got != 52*time.Second {
+		t.Errorf("Test clock: got %v left after the increment, want 52s", got)
+	}
+
+	clock.start(false)
+	current = current.Add(time.Minute)
+	if !clock.flagged() || clock.Remaining(false) != 0 {
+		t.Errorf("Test clock: want black flagged, got %v left", clock.Remaining(false))
+	}
+	clock.stop()
+
+	// the delay gives back the time used up to it, never more
+	delay := newClock(TimeControl{Base: time.Minute, Delay: 5 * time.Second}, func() {})
+	delay.start(true)
+	current = current.Add(3 * time.Second)
+	if got := delay.punch(); got != time.Minute {
+		t.Errorf("Test clock: got %v left within the delay, want 1m", got)
+	}
+	delay.start(false)
+	current = current.Add(8 * time.Second)
+	if got := delay.punch(); got != 57*time.Second {
+		t.Errorf("Test clock: got %v left past the delay, want 57s", got)
+	}
+}
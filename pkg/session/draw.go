@@ -0,0 +1,166 @@
+package session
+
+import (
+	"errors"
+
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+type drawResponse struct {
+	Type     string `json:"type"`
+	PlayerID string `json:"player_id"`
+}
+
+/*
+Offer a draw to the opponent. The offer stands until the opponent answers
+it or the player makes a move
+*/
+func OfferDraw(sessionID, playerID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	session, err := activeSession(sessionID, playerID)
+	if err != nil {
+		return err
+	}
+	switch session.DrawOfferer {
+	case playerID:
+		return errors.New("draw already offered")
+	case "":
+	default:
+		return errors.New("draw offered by opponent")
+	}
+
+	if p := opponent(session, playerID); p != nil && p.Bot != nil {
+		return errBotOpponent
+	}
+
+	session.DrawOfferer = playerID
+	notifyPlayer(opponent(session, playerID), drawResponse{
+		Type:     "draw_offer",
+		PlayerID: playerID,
+	})
+
+	return nil
+}
+
+/*
+Accept the draw offered by the opponent, ending the game in a draw by agreement
+*/
+func AcceptDraw(sessionID, playerID string) error {
+	mu.Lock()
+
+	session, err := pendingDrawOffer(sessionID, playerID)
+	if err != nil {
+		mu.Unlock()
+		return err
+	}
+	if err := session.Game.AgreeDraw(); err != nil {
+		mu.Unlock()
+		return err
+	}
+	session.DrawOfferer = ""
+	if session.Clock != nil {
+		session.Clock.stop()
+	}
+
+	logging.Info("draw agreed",
+		zap.String("session_id", sessionID),
+		zap.String("player_id", playerID),
+	)
+
+	mu.Unlock()
+
+	gameOverHandler(session, sessionID)
+
+	return nil
+}
+
+/*
+Decline the draw offered by the opponent
+*/
+func DeclineDraw(sessionID, playerID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	session, err := pendingDrawOffer(sessionID, playerID)
+	if err != nil {
+		return err
+	}
+
+	notifyPlayer(session.Players[session.DrawOfferer], drawResponse{
+		Type:     "draw_declined",
+		PlayerID: playerID,
+	})
+	session.DrawOfferer = ""
+
+	return nil
+}
+
+/*
+Claim a draw by threefold repetition or by the fifty-move rule.
+A valid claim ends the game without the opponent's consent
+*/
+func ClaimDraw(sessionID, playerID string) error {
+	mu.Lock()
+
+	session, err := activeSession(sessionID, playerID)
+	if err != nil {
+		mu.Unlock()
+		return err
+	}
+	if err := session.Game.ClaimDraw(playerID); err != nil {
+		mu.Unlock()
+		return err
+	}
+	session.DrawOfferer = ""
+	if session.Clock != nil {
+		session.Clock.stop()
+	}
+
+	logging.Info("draw claimed",
+		zap.String("session_id", sessionID),
+		zap.String("player_id", playerID),
+		zap.String("status", session.Game.GetStatus()),
+	)
+
+	mu.Unlock()
+
+	gameOverHandler(session, sessionID)
+
+	return nil
+}
+
+// withdraw the draw offer of the player who just moved
+func withdrawDrawOffer(session *GameSession, playerID string) {
+	if session.DrawOfferer != playerID {
+		return
+	}
+	session.DrawOfferer = ""
+	notifyPlayer(opponent(session, playerID), drawResponse{
+		Type:     "draw_withdrawn",
+		PlayerID: playerID,
+	})
+}
+
+func pendingDrawOffer(sessionID, playerID string) (*GameSession, error) {
+	session, err := activeSession(sessionID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if session.DrawOfferer == "" || session.DrawOfferer == playerID {
+		return nil, errors.New("no draw offered by opponent")
+	}
+	return session, nil
+}
+
+// send a message to the player, unless disconnected or a bot
+func notifyPlayer(player *Player, v any) {
+	if !player.connected() {
+		return
+	}
+	if err := player.WriteJSON(v); err != nil {
+		logging.Info("ws write", zap.Error(err))
+	}
+}
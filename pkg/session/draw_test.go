@@ -0,0 +1,98 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+func TestDrawOffer(t *testing.T) {
+	defer func(handler func(*GameSession, string)) { gameOverHandler = handler }(gameOverHandler)
+	ended := ""
+	SetGameOverHandler(func(session *GameSession, sessionID string) {
+		ended = session.Game.GetStatus()
+		CloseSession(sessionID)
+	})
+
+	sessionID := "draw-offer-test"
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, &Player{ID: "white"}, &Player{ID: "black"}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+	session := gameSessions[sessionID]
+
+	if err := AcceptDraw(sessionID, "black"); err == nil {
+		t.Error("Test draw offer: want error accepting with no offer")
+	}
+	if err := OfferDraw(sessionID, "white"); err != nil {
+		t.Fatal(err)
+	}
+	if err := OfferDraw(sessionID, "white"); err == nil {
+		t.Error("Test draw offer: want error for a draw already offered")
+	}
+	if err := OfferDraw(sessionID, "black"); err == nil {
+		t.Error("Test draw offer: want error offering while the opponent's offer stands")
+	}
+	if err := AcceptDraw(sessionID, "white"); err == nil {
+		t.Error("Test draw offer: want error accepting the player's own offer")
+	}
+	if err := DeclineDraw(sessionID, "black"); err != nil {
+		t.Fatal(err)
+	}
+	if session.DrawOfferer != "" {
+		t.Errorf("Test draw offer: got offer by %q after declining", session.DrawOfferer)
+	}
+
+	// the offer stands through the opponent's move and is withdrawn by the offerer's
+	if err := OfferDraw(sessionID, "white"); err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, sessionID, "e2-e4")
+	if session.DrawOfferer != "" {
+		t.Errorf("Test draw offer: got offer by %q after the offerer moved", session.DrawOfferer)
+	}
+	if err := OfferDraw(sessionID, "white"); err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, sessionID, "e7-e5")
+	if session.DrawOfferer != "white" {
+		t.Errorf("Test draw offer: got offer by %q after the opponent moved", session.DrawOfferer)
+	}
+
+	if err := AcceptDraw(sessionID, "black"); err != nil {
+		t.Fatal(err)
+	}
+	if ended != "DRAW_AGREEMENT" || session.Game.Result() != "1/2-1/2" {
+		t.Errorf("Test draw offer: got status %q, result %s", ended, session.Game.Result())
+	}
+}
+
+func TestClaimDraw(t *testing.T) {
+	defer func(handler func(*GameSession, string)) { gameOverHandler = handler }(gameOverHandler)
+	ended := ""
+	SetGameOverHandler(func(session *GameSession, sessionID string) {
+		ended = session.Game.GetStatus()
+		CloseSession(sessionID)
+	})
+
+	sessionID := "claim-draw-test"
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, &Player{ID: "white"}, &Player{ID: "black"}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+
+	if err := ClaimDraw(sessionID, "white"); err == nil {
+		t.Error("Test claim draw: want error with no draw to claim")
+	}
+	// the starting position occurs for the third time
+	playMoves(t, sessionID, "g1-f3", "g8-f6", "f3-g1", "f6-g8", "g1-f3", "g8-f6", "f3-g1", "f6-g8")
+	if err := ClaimDraw(sessionID, "black"); err != nil {
+		t.Fatal(err)
+	}
+	if ended != "THREEFOLD_REPETITION" {
+		t.Errorf("Test claim draw: got status %q", ended)
+	}
+	if err := ClaimDraw(sessionID, "white"); err == nil {
+		t.Error("Test claim draw: want error once the game is over")
+	}
+}
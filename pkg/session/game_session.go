@@ -5,23 +5,47 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/yelaco/go-chess-server/internal/game"
 	"github.com/yelaco/go-chess-server/pkg/logging"
-	"github.com/yelaco/go-chess-server/pkg/utils"
 	"go.uber.org/zap"
 )
 
 type GameSession struct {
-	Players map[string]*Player
-	Game    *game.Game
+	Players           map[string]*Player
+	Game              *game.Game
+	TakebackRequester string             // id of the player waiting for a takeback answer
+	DrawOfferer       string             // id of the player whose draw offer is pending
+	Clock             *Clock             // nil for games without time control
+	Spectators        map[string]*Player // connections watching the game, by connection id
+	Chat              []ChatMessage
+	Muted             map[string]bool        // players who muted their opponent in the chat
+	chatTimes         map[string][]time.Time // when each user last sent chat messages, for rate limiting
 }
 
 type GameState struct {
+	Variant     string       `json:"variant"`
 	Status      string       `json:"status"`
 	Board       [8][8]string `json:"board"`
+	Fen         string       `json:"fen"`
 	IsWhiteTurn bool         `json:"is_white"`
+	LegalMoves  []string     `json:"legal_moves"`
+	Pockets     *Pockets     `json:"pockets,omitempty"`
+	Clocks      *Clocks      `json:"clocks,omitempty"`
+	Spectators  int          `json:"spectators"`
+}
+
+// time left to each player in milliseconds, in games with a time control
+type Clocks struct {
+	White int64 `json:"white"`
+	Black int64 `json:"black"`
+}
+
+// pieces in hand of each side as FEN letters, in variants where captured pieces can be dropped
+type Pockets struct {
+	White string `json:"white"`
+	Black string `json:"black"`
 }
 
 type SessionResponse struct {
@@ -38,26 +62,53 @@ var (
 	mu              sync.RWMutex
 	gameOverHandler = func(session *GameSession, sessionID string) {
 		CloseSession(sessionID)
-		for _, player := range session.Players {
+		for _, player := range append(session.connectedPlayers(), session.GetSpectators()...) {
 			player.Conn.Close()
 		}
 	}
 )
 
-func InitSession(sessionID string, player1, player2 *Player) {
+/*
+Start a session playing the given variant from its starting position.
+With a time control, white's clock starts running right away
+*/
+func InitSession(sessionID string, variant game.Variant, control TimeControl, player1, player2 *Player) error {
 	playersMap := map[string]*Player{
 		player1.ID: player1,
 		player2.ID: player2,
 	}
-	gameSessions[sessionID] = &GameSession{
-		Players: playersMap,
-		Game:    game.InitGame([2]string{player1.ID, player2.ID}),
+	g, err := game.InitGameVariant([2]string{player1.ID, player2.ID}, variant)
+	if err != nil {
+		return err
+	}
+	session := &GameSession{
+		Players:    playersMap,
+		Game:       g,
+		Spectators: map[string]*Player{},
+		Muted:      map[string]bool{},
+		chatTimes:  map[string][]time.Time{},
+	}
+	if control.Base > 0 {
+		session.Clock = newClock(control, func() { flagFall(sessionID) })
+		session.Clock.start(true)
 	}
+	mu.Lock()
+	defer mu.Unlock()
+	gameSessions[sessionID] = session
+	return nil
 }
 
 func CloseSession(sessionID string) {
 	mu.Lock()
 	defer mu.Unlock()
+	if session, exists := gameSessions[sessionID]; exists {
+		if session.Clock != nil {
+			session.Clock.stop()
+		}
+		for connID := range session.Spectators {
+			delete(spectating, connID)
+		}
+	}
 	delete(gameSessions, sessionID)
 }
 
@@ -67,7 +118,14 @@ func SetGameOverHandler(govHandler func(*GameSession, string)) {
 
 func StartGame(session *GameSession) {
 	for _, player := range session.Players {
-		err := player.Conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"start"}`))
+		if !player.connected() {
+			continue
+		}
+		err := player.WriteJSON(struct {
+			Type string `json:"type"`
+		}{
+			Type: "start",
+		})
 		if err != nil {
 			log.Println("Error sending start message:", err)
 		}
@@ -75,17 +133,67 @@ func StartGame(session *GameSession) {
 }
 
 func GetGameState(sessionID string) (GameState, error) {
+	// legal move generation simulates moves on the board, so readers need exclusive access
+	mu.Lock()
+	defer mu.Unlock()
+	session, exists := gameSessions[sessionID]
+	if exists {
+		return gameState(session), nil
+	}
+	return GameState{}, errors.New("invalid session id")
+}
+
+// state of the game of the session, read under the lock
+func gameState(session *GameSession) GameState {
+	state := GameState{
+		Variant:     session.Game.Variant().Name(),
+		Status:      session.Game.GetStatus(),
+		Board:       session.Game.GetBoard(),
+		Fen:         session.Game.FEN(),
+		IsWhiteTurn: session.Game.GetCurrentTurn(),
+		LegalMoves:  session.Game.LegalMoves(),
+		Spectators:  len(session.Spectators),
+	}
+	if session.Game.HasPockets() {
+		state.Pockets = &Pockets{
+			White: session.Game.Pocket(true),
+			Black: session.Game.Pocket(false),
+		}
+	}
+	if session.Clock != nil {
+		state.Clocks = &Clocks{
+			White: session.Clock.Remaining(true).Milliseconds(),
+			Black: session.Clock.Remaining(false).Milliseconds(),
+		}
+	}
+	return state
+}
+
+/*
+Return the ids of the white and the black player of an ongoing session
+*/
+func GetPlayerIds(sessionID string) (string, string, error) {
 	mu.RLock()
 	defer mu.RUnlock()
 	session, exists := gameSessions[sessionID]
 	if exists {
-		return GameState{
-			Status:      session.Game.GetStatus(),
-			Board:       session.Game.GetBoard(),
-			IsWhiteTurn: session.Game.GetCurrentTurn(),
-		}, nil
+		whiteID, blackID := session.Game.GetPlayerIds()
+		return whiteID, blackID, nil
 	}
-	return GameState{}, errors.New("invalid session id")
+	return "", "", errors.New("invalid session id")
+}
+
+/*
+Export the game of an ongoing session in Portable Game Notation
+*/
+func GetGamePGN(sessionID string, tags game.PGNTags) (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	session, exists := gameSessions[sessionID]
+	if exists {
+		return session.Game.PGN(tags), nil
+	}
+	return "", errors.New("invalid session id")
 }
 
 func GetPlayerState(sessionID, playerID string) (PlayerState, error) {
@@ -145,109 +253,214 @@ func PlayerLeave(sessionID, playerID string) error {
 	return errors.New("invalid session id")
 }
 
-func ProcessFenMove(sessionID, playerID, fenMove string) {
+/*
+Resign the game of the session for the player, whoever's turn it is.
+The game ends and goes through the game over handler
+*/
+func Resign(sessionID, playerID string) error {
 	mu.Lock()
-
-	type gameStateResponse struct {
-		Status      string `json:"status"`
-		BoardFen    string `json:"board_fen"`
-		IsWhiteTurn bool   `json:"is_white_turn"`
+	session, err := activeSession(sessionID, playerID)
+	if err != nil {
+		mu.Unlock()
+		return err
 	}
-
-	type sessionResponse struct {
-		Type      string            `json:"type"`
-		GameState gameStateResponse `json:"game_state"`
+	if err := session.Game.Resign(playerID); err != nil {
+		mu.Unlock()
+		return err
 	}
+	if session.Clock != nil {
+		session.Clock.stop()
+	}
+
+	logging.Info("player resigned",
+		zap.String("session_id", sessionID),
+		zap.String("player_id", playerID),
+	)
 
-	type errorResponse struct {
-		Type  string `json:"type"`
-		Error string `json:"error"`
+	mu.Unlock()
+
+	gameOverHandler(session, sessionID)
+
+	return nil
+}
+
+type gameStateResponse struct {
+	Variant     string   `json:"variant"`
+	Status      string   `json:"status"`
+	BoardFen    string   `json:"board_fen"`
+	IsWhiteTurn bool     `json:"is_white_turn"`
+	LegalMoves  []string `json:"legal_moves"`
+	Pockets     *Pockets `json:"pockets,omitempty"`
+	Clocks      *Clocks  `json:"clocks,omitempty"`
+	Spectators  int      `json:"spectators"`
+}
+
+func newGameStateResponse(state GameState) gameStateResponse {
+	return gameStateResponse{
+		Variant:     state.Variant,
+		Status:      state.Status,
+		BoardFen:    state.Fen,
+		IsWhiteTurn: state.IsWhiteTurn,
+		LegalMoves:  state.LegalMoves,
+		Pockets:     state.Pockets,
+		Clocks:      state.Clocks,
+		Spectators:  state.Spectators,
 	}
+}
+
+type sessionResponse struct {
+	Type      string            `json:"type"`
+	GameState gameStateResponse `json:"game_state"`
+}
+
+type errorResponse struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+func ProcessFenMove(sessionID, playerID, fenMove string) {
+	mu.Lock()
 
 	// TODO: validate if fen match the current board state
-	move := strings.Split(fenMove, " ")[1]
+	// the move is the last field, after the fen the client played on
+	move := ""
+	if fields := strings.Fields(fenMove); len(fields) > 0 {
+		move = fields[len(fields)-1]
+	}
 
 	session, exists := gameSessions[sessionID]
-	if exists {
-		pos, parseErr := game.ParseMove(move)
-		if parseErr != nil {
-			logging.Warn("invalid move",
-				zap.String("session_id", sessionID),
-				zap.String("player_id", playerID),
-				zap.String("move", move),
-				zap.String("error", parseErr.Error()),
-			)
-			if err := session.Players[playerID].Conn.WriteJSON(errorResponse{
-				Type:  "error",
-				Error: "invalid move: " + parseErr.Error(),
-			}); err != nil {
-				logging.Info("ws write", zap.Error(err))
-			}
-			mu.Unlock()
-			return
-		}
+	if !exists {
+		mu.Unlock()
+		return
+	}
 
-		err := session.Game.MakeMove(playerID, pos[0], pos[1])
-		if err != nil {
-			logging.Warn("invalid move",
-				zap.String("session_id", sessionID),
-				zap.String("player_id", playerID),
-				zap.String("move", move),
-				zap.String("error", err.Error()),
-			)
-			if err := session.Players[playerID].Conn.WriteJSON(errorResponse{
-				Type:  "error",
-				Error: "invalid move: " + err.Error(),
-			}); err != nil {
-				logging.Info("ws write", zap.Error(err))
-			}
-			mu.Unlock()
-			return
-		}
+	// a move played after the flag fell is too late
+	if session.Clock != nil && session.Clock.flagged() {
+		mu.Unlock()
+		flagFall(sessionID)
+		return
+	}
 
-		logging.Info("valid move",
+	startPos, endPos, parseErr := session.Game.ResolveMove(move)
+	if parseErr != nil {
+		logging.Warn("invalid move",
 			zap.String("session_id", sessionID),
 			zap.String("player_id", playerID),
 			zap.String("move", move),
+			zap.String("error", parseErr.Error()),
 		)
+		sendError(session.Players[playerID], "invalid move: "+parseErr.Error())
+		mu.Unlock()
+		return
+	}
 
+	err := session.Game.MakeMove(playerID, startPos, endPos)
+	if err != nil {
+		logging.Warn("invalid move",
+			zap.String("session_id", sessionID),
+			zap.String("player_id", playerID),
+			zap.String("move", move),
+			zap.String("error", err.Error()),
+		)
+		sendError(session.Players[playerID], "invalid move: "+err.Error())
 		mu.Unlock()
+		return
+	}
 
-		// notify players about the new board state
-		for _, player := range session.Players {
-			gameState, err := GetGameState(sessionID)
-			if err != nil {
-				logging.Error("invalid session id for game state")
-				if err := player.Conn.WriteJSON(errorResponse{
-					Type:  "error",
-					Error: "coulnd't retrieve game state",
-				}); err != nil {
-					logging.Info("ws write", zap.Error(err))
-				}
-				return
-			}
+	// a move cancels any pending takeback request and the mover's draw offer
+	session.TakebackRequester = ""
+	withdrawDrawOffer(session, playerID)
 
-			if player == nil {
-				continue
-			}
+	if session.Clock != nil {
+		remaining := session.Clock.punch()
+		session.Game.SetMoveClock(len(session.Game.GetAllMoves())-1, remaining)
+		if !session.Game.IsOver() {
+			session.Clock.start(session.Game.GetCurrentTurn())
+		}
+	}
 
-			if err := player.Conn.WriteJSON(sessionResponse{
-				Type: "session",
-				GameState: gameStateResponse{
-					Status:      gameState.Status,
-					BoardFen:    utils.BoardToFen(gameState.Board),
-					IsWhiteTurn: gameState.IsWhiteTurn,
-				},
-			}); err != nil {
-				logging.Error("couldn't notify player ", zap.String("player_id", playerID))
-			}
-			logging.Info("game state",
-				zap.Bool("is_white_turn", gameState.IsWhiteTurn),
-			)
+	logging.Info("valid move",
+		zap.String("session_id", sessionID),
+		zap.String("player_id", playerID),
+		zap.String("move", move),
+	)
+
+	// read under the lock, the clock, bots and resignations change the game meanwhile
+	over := session.Game.IsOver()
+	mu.Unlock()
+
+	notifyGameState(sessionID, session)
+
+	if over {
+		gameOverHandler(session, sessionID)
+		return
+	}
+	go PlayBotMove(sessionID)
+}
+
+/*
+Send the current game state to the players and the spectators of the session
+*/
+func notifyGameState(sessionID string, session *GameSession) {
+	gameState, err := GetGameState(sessionID)
+	if err != nil {
+		logging.Error("invalid session id for game state")
+		for _, player := range session.Players {
+			sendError(player, "coulnd't retrieve game state")
+		}
+		return
+	}
+
+	mu.RLock()
+	audience := session.connectedPlayers()
+	mu.RUnlock()
+	for _, player := range append(audience, session.GetSpectators()...) {
+		if err := player.WriteJSON(sessionResponse{
+			Type:      "session",
+			GameState: newGameStateResponse(gameState),
+		}); err != nil {
+			logging.Error("couldn't notify player ", zap.String("player_id", player.ID))
 		}
+	}
+	logging.Info("game state",
+		zap.String("session_id", sessionID),
+		zap.Bool("is_white_turn", gameState.IsWhiteTurn),
+	)
+}
 
-		if session.Game.IsOver() {
-			gameOverHandler(session, sessionID)
+// session of an ongoing game the player takes part in
+func activeSession(sessionID, playerID string) (*GameSession, error) {
+	session, exists := gameSessions[sessionID]
+	if !exists {
+		return nil, errors.New("invalid session id")
+	}
+	if _, ok := session.Players[playerID]; !ok {
+		return nil, errors.New("player id not in the session")
+	}
+	if session.Game.IsOver() {
+		return nil, errors.New("game is over")
+	}
+	return session, nil
+}
+
+// the other player of the session, nil if disconnected
+func opponent(session *GameSession, playerID string) *Player {
+	for id, player := range session.Players {
+		if id != playerID {
+			return player
 		}
 	}
+	return nil
+}
+
+func sendError(player *Player, msg string) {
+	if !player.connected() {
+		return
+	}
+	if err := player.WriteJSON(errorResponse{
+		Type:  "error",
+		Error: msg,
+	}); err != nil {
+		logging.Info("ws write", zap.Error(err))
+	}
 }
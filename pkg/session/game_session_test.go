@@ -0,0 +1,45 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+func TestResign(t *testing.T) {
+	defer func(handler func(*GameSession, string)) { gameOverHandler = handler }(gameOverHandler)
+	ended := ""
+	SetGameOverHandler(func(session *GameSession, sessionID string) {
+		ended = session.Game.GetStatus()
+		CloseSession(sessionID)
+	})
+
+	sessionID := "resign-test"
+	if err := InitSession(sessionID, game.Standard, TimeControl{Base: time.Minute}, &Player{ID: "white"}, &Player{ID: "black"}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+	session := gameSessions[sessionID]
+
+	if err := Resign(sessionID, "coach"); err == nil {
+		t.Error("Test resign: want error for a player not in the session")
+	}
+	if err := Resign("invalid", "white"); err == nil {
+		t.Error("Test resign: want error for an invalid session id")
+	}
+
+	// black resigns on white's turn
+	if err := Resign(sessionID, "black"); err != nil {
+		t.Fatal(err)
+	}
+	if ended != "BLACK_RESIGN" || session.Game.Result() != "1-0" {
+		t.Errorf("Test resign: got status %q, result %s", ended, session.Game.Result())
+	}
+	if session.Clock.running >= 0 {
+		t.Error("Test resign: want the clock stopped")
+	}
+	if err := Resign(sessionID, "white"); err == nil {
+		t.Error("Test resign: want error once the game is over")
+	}
+}
@@ -1,8 +1,25 @@
 package session
 
-import "github.com/gorilla/websocket"
+import (
+	"github.com/gorilla/websocket"
+	"github.com/yelaco/go-chess-server/pkg/corenet"
+)
 
 type Player struct {
 	Conn *websocket.Conn
 	ID   string `json:"id"`
+	Bot  Bot    `json:"-"` // set for a bot player, which has no connection
+}
+
+/*
+Send a message as JSON to the player. Writes to the connection are serialized,
+as clocks, bots and chat messages of the session write from their own goroutines
+*/
+func (p *Player) WriteJSON(v any) error {
+	return corenet.WriteJSON(p.Conn, v)
+}
+
+// bots play on the server and have no connection to write to
+func (p *Player) connected() bool {
+	return p != nil && p.Conn != nil
 }
@@ -0,0 +1,207 @@
+package session
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/gorilla/websocket"
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// session watched through each spectator connection, by connection id
+var spectating = map[string]string{}
+
+type spectateResponse struct {
+	Type      string            `json:"type"`
+	SessionID string            `json:"session_id"`
+	GameState gameStateResponse `json:"game_state"`
+	Moves     []string          `json:"moves"`
+}
+
+type spectatorsResponse struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+/*
+ * LiveSession
+ * Summary of an ongoing game that can be watched
+ */
+type LiveSession struct {
+	SessionID   string `json:"session_id"`
+	WhiteID     string `json:"white_id"`
+	BlackID     string `json:"black_id"`
+	Variant     string `json:"variant"`
+	TimeControl string `json:"time_control"`
+	Fen         string `json:"fen"`
+	Moves       int    `json:"moves"`
+	Spectators  int    `json:"spectators"`
+}
+
+/*
+Subscribe a connection to the updates of an ongoing session. The spectator gets the
+current position and moves right away, then every game state like the players,
+who are told how many spectators are watching
+*/
+func Spectate(sessionID, connID string, spectator *Player) error {
+	mu.Lock()
+
+	session, exists := gameSessions[sessionID]
+	if !exists {
+		mu.Unlock()
+		return errors.New("invalid session id")
+	}
+	if session.Game.IsOver() {
+		mu.Unlock()
+		return errors.New("game is over")
+	}
+	if _, ok := session.Players[spectator.ID]; ok {
+		mu.Unlock()
+		return errors.New("player in the session")
+	}
+	if _, ok := spectating[connID]; ok {
+		mu.Unlock()
+		return errors.New("already spectating")
+	}
+
+	session.Spectators[connID] = spectator
+	spectating[connID] = sessionID
+	state := gameState(session)
+	moves := session.Game.GetAllMoves()
+	players := session.connectedPlayers()
+
+	logging.Info("spectator joined",
+		zap.String("session_id", sessionID),
+		zap.String("player_id", spectator.ID),
+		zap.Int("spectators", state.Spectators),
+	)
+
+	mu.Unlock()
+
+	notifyPlayer(spectator, spectateResponse{
+		Type:      "spectating",
+		SessionID: sessionID,
+		GameState: newGameStateResponse(state),
+		Moves:     moves,
+	})
+	notifySpectatorCount(players, state.Spectators)
+
+	return nil
+}
+
+/*
+Unsubscribe a spectator connection from the session it watches, if any
+*/
+func StopSpectating(connID string) {
+	mu.Lock()
+
+	sessionID, ok := spectating[connID]
+	if !ok {
+		mu.Unlock()
+		return
+	}
+	delete(spectating, connID)
+	session, exists := gameSessions[sessionID]
+	if !exists {
+		mu.Unlock()
+		return
+	}
+	delete(session.Spectators, connID)
+	count := len(session.Spectators)
+	players := session.connectedPlayers()
+
+	logging.Info("spectator left",
+		zap.String("session_id", sessionID),
+		zap.Int("spectators", count),
+	)
+
+	mu.Unlock()
+
+	notifySpectatorCount(players, count)
+}
+
+/*
+Check if the connection is the one the player of the session plays through,
+so spectators and other connections can't act for the player
+*/
+func PlaysThrough(sessionID, playerID string, conn *websocket.Conn) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	session, exists := gameSessions[sessionID]
+	if !exists {
+		return false
+	}
+	player, ok := session.Players[playerID]
+	return ok && player.connected() && player.Conn == conn
+}
+
+/*
+Return the spectators watching the session
+*/
+func (s *GameSession) GetSpectators() []*Player {
+	mu.RLock()
+	defer mu.RUnlock()
+	spectators := make([]*Player, 0, len(s.Spectators))
+	for _, spectator := range s.Spectators {
+		spectators = append(spectators, spectator)
+	}
+	return spectators
+}
+
+/*
+Return the ongoing games, the latest first
+*/
+func LiveSessions() []LiveSession {
+	mu.RLock()
+	defer mu.RUnlock()
+	live := []LiveSession{}
+	for sessionID, session := range gameSessions {
+		if session.Game.IsOver() {
+			continue
+		}
+		whiteID, blackID := session.Game.GetPlayerIds()
+		control := TimeControl{}
+		if session.Clock != nil {
+			control = session.Clock.control
+		}
+		live = append(live, LiveSession{
+			SessionID:   sessionID,
+			WhiteID:     whiteID,
+			BlackID:     blackID,
+			Variant:     session.Game.Variant().Name(),
+			TimeControl: control.String(),
+			Fen:         session.Game.FEN(),
+			Moves:       len(session.Game.GetAllMoves()),
+			Spectators:  len(session.Spectators),
+		})
+	}
+	// session ids are creation times
+	sort.Slice(live, func(i, j int) bool {
+		if len(live[i].SessionID) != len(live[j].SessionID) {
+			return len(live[i].SessionID) > len(live[j].SessionID)
+		}
+		return live[i].SessionID > live[j].SessionID
+	})
+	return live
+}
+
+// players of the session with a connection, read under the lock
+func (s *GameSession) connectedPlayers() []*Player {
+	players := []*Player{}
+	for _, player := range s.Players {
+		if player.connected() {
+			players = append(players, player)
+		}
+	}
+	return players
+}
+
+func notifySpectatorCount(players []*Player, count int) {
+	for _, player := range players {
+		notifyPlayer(player, spectatorsResponse{
+			Type:  "spectators",
+			Count: count,
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+func TestSpectate(t *testing.T) {
+	sessionID := "spectate-test"
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, &Player{ID: "white"}, &Player{ID: "black"}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+
+	if err := Spectate(sessionID, "conn-1", &Player{ID: "coach"}); err != nil {
+		t.Fatal(err)
+	}
+	for connID, spectator := range map[string]*Player{
+		"conn-1": {ID: "teammate"},
+		"conn-2": {ID: "white"},
+	} {
+		if err := Spectate(sessionID, connID, spectator); err == nil {
+			t.Errorf("Test spectate: want error for %s on %s", spectator.ID, connID)
+		}
+	}
+	if err := Spectate("invalid", "conn-3", &Player{ID: "coach"}); err == nil {
+		t.Error("Test spectate: want error for an invalid session id")
+	}
+
+	state, err := GetGameState(sessionID)
+	if err != nil || state.Spectators != 1 {
+		t.Errorf("Test spectate: got %d spectators, %v", state.Spectators, err)
+	}
+	found := false
+	for _, live := range LiveSessions() {
+		if live.SessionID == sessionID {
+			found = true
+			if live.WhiteID != "white" || live.Spectators != 1 || live.TimeControl != "-" {
+				t.Errorf("Test spectate: got live session %+v", live)
+			}
+		}
+	}
+	if !found {
+		t.Error("Test spectate: session not listed as live")
+	}
+	// spectators play through no connection of the session
+	if PlaysThrough(sessionID, "coach", nil) || PlaysThrough(sessionID, "white", nil) {
+		t.Error("Test spectate: want no player connection")
+	}
+
+	StopSpectating("conn-1")
+	if state, _ := GetGameState(sessionID); state.Spectators != 0 {
+		t.Errorf("Test spectate: got %d spectators after leaving", state.Spectators)
+	}
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"errors"
+
+	"github.com/yelaco/go-chess-server/pkg/logging"
+	"go.uber.org/zap"
+)
+
+type takebackResponse struct {
+	Type     string `json:"type"`
+	PlayerID string `json:"player_id"`
+}
+
+/*
+Ask the opponent to take back the last move of the player.
+The request is cancelled by the next move played in the session
+*/
+func RequestTakeback(sessionID, playerID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	session, err := activeSession(sessionID, playerID)
+	if err != nil {
+		return err
+	}
+	if p := opponent(session, playerID); p != nil && p.Bot != nil {
+		return errBotOpponent
+	}
+	if session.TakebackRequester != "" {
+		return errors.New("takeback already requested")
+	}
+	if len(session.Game.GetAllMoves()) < takebackPlies(session, playerID) {
+		return errors.New("no move to take back")
+	}
+
+	session.TakebackRequester = playerID
+	notifyPlayer(opponent(session, playerID), takebackResponse{
+		Type:     "takeback_request",
+		PlayerID: playerID,
+	})
+
+	return nil
+}
+
+/*
+Accept the takeback request of the opponent. The requester's last move is taken back,
+along with the reply to it if the player has already answered
+*/
+func AcceptTakeback(sessionID, playerID string) error {
+	mu.Lock()
+
+	session, err := pendingTakeback(sessionID, playerID)
+	if err != nil {
+		mu.Unlock()
+		return err
+	}
+
+	for range takebackPlies(session, session.TakebackRequester) {
+		if err := session.Game.UndoMove(); err != nil {
+			mu.Unlock()
+			return err
+		}
+	}
+	session.TakebackRequester = ""
+	if session.Clock != nil {
+		session.Clock.start(session.Game.GetCurrentTurn())
+	}
+
+	logging.Info("takeback accepted",
+		zap.String("session_id", sessionID),
+		zap.String("player_id", playerID),
+	)
+
+	mu.Unlock()
+
+	notifyGameState(sessionID, session)
+
+	return nil
+}
+
+/*
+Decline the takeback request of the opponent
+*/
+func DeclineTakeback(sessionID, playerID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	session, err := pendingTakeback(sessionID, playerID)
+	if err != nil {
+		return err
+	}
+
+	notifyPlayer(session.Players[session.TakebackRequester], takebackResponse{
+		Type:     "takeback_declined",
+		PlayerID: playerID,
+	})
+	session.TakebackRequester = ""
+
+	return nil
+}
+
+func pendingTakeback(sessionID, playerID string) (*GameSession, error) {
+	session, err := activeSession(sessionID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if session.TakebackRequester == "" || session.TakebackRequester == playerID {
+		return nil, errors.New("no takeback requested by opponent")
+	}
+	return session, nil
+}
+
+// number of moves to undo so it's the requester's turn again
+func takebackPlies(session *GameSession, requesterID string) int {
+	isWhiteSide, _ := session.Game.GetPlayerSide(requesterID)
+	if isWhiteSide == session.Game.GetCurrentTurn() {
+		return 2
+	}
+	return 1
+}
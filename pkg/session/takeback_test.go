@@ -0,0 +1,96 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/yelaco/go-chess-server/internal/game"
+)
+
+// play the moves in the session, each by the player whose turn it is
+func playMoves(t *testing.T, sessionID string, moves ...string) {
+	t.Helper()
+	for _, m := range moves {
+		mu.RLock()
+		session := gameSessions[sessionID]
+		before := len(session.Game.GetAllMoves())
+		player := playerToMove(session)
+		mu.RUnlock()
+
+		ProcessFenMove(sessionID, player.ID, m)
+
+		mu.RLock()
+		played := len(session.Game.GetAllMoves()) == before+1
+		mu.RUnlock()
+		if !played {
+			t.Fatalf("couldn't play %s", m)
+		}
+	}
+}
+
+func TestTakeback(t *testing.T) {
+	sessionID := "takeback-test"
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, &Player{ID: "white"}, &Player{ID: "black"}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+	session := gameSessions[sessionID]
+
+	if err := RequestTakeback(sessionID, "white"); err == nil {
+		t.Error("Test takeback: want error with no move to take back")
+	}
+	playMoves(t, sessionID, "e2-e4", "e7-e5")
+
+	// on white's turn, black's reply is taken back with white's move
+	if err := RequestTakeback(sessionID, "white"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RequestTakeback(sessionID, "white"); err == nil {
+		t.Error("Test takeback: want error for a takeback already requested")
+	}
+	if err := AcceptTakeback(sessionID, "white"); err == nil {
+		t.Error("Test takeback: want error accepting the player's own request")
+	}
+	if err := AcceptTakeback(sessionID, "black"); err != nil {
+		t.Fatal(err)
+	}
+	if moves := session.Game.GetAllMoves(); len(moves) != 0 || session.TakebackRequester != "" {
+		t.Errorf("Test takeback: got moves %v, requester %q after accepting", moves, session.TakebackRequester)
+	}
+
+	// on black's turn, only white's last move is taken back
+	playMoves(t, sessionID, "d2-d4")
+	if err := RequestTakeback(sessionID, "white"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeclineTakeback(sessionID, "black"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeclineTakeback(sessionID, "black"); err == nil {
+		t.Error("Test takeback: want error declining with no request")
+	}
+	if moves := session.Game.GetAllMoves(); len(moves) != 1 || session.TakebackRequester != "" {
+		t.Errorf("Test takeback: got moves %v, requester %q after declining", moves, session.TakebackRequester)
+	}
+
+	// the next move cancels the request
+	if err := RequestTakeback(sessionID, "white"); err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, sessionID, "d7-d5")
+	if err := AcceptTakeback(sessionID, "black"); err == nil || session.TakebackRequester != "" {
+		t.Errorf("Test takeback: got %v accepting a request cancelled by a move", err)
+	}
+}
+
+func TestTakebackBot(t *testing.T) {
+	sessionID := "takeback-bot-test"
+	if err := InitSession(sessionID, game.Standard, TimeControl{}, &Player{ID: "human"}, &Player{ID: "bot", Bot: firstMoveBot{}}); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSession(sessionID)
+
+	playMoves(t, sessionID, "e2-e4")
+	if err := RequestTakeback(sessionID, "human"); err != errBotOpponent {
+		t.Errorf("Test takeback bot: got %v, want %v", err, errBotOpponent)
+	}
+}
@@ -95,7 +95,7 @@ func viewPreviousMatches() tview.Primitive {
 				AddButtons([]string{"Yes", "No"}).
 				SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 					if buttonLabel == "Yes" {
-						app.SetRoot(viewMatch(session.Moves), true).Run()
+						app.SetRoot(viewMatch(session, getAnalysis(session.SessionID)), true).Run()
 					} else {
 						app.SetRoot(viewPreviousMatches(), true).Run()
 					}
@@ -111,26 +111,34 @@ func viewPreviousMatches() tview.Primitive {
 	return list
 }
 
-func viewMatch(moves []string) *tview.Flex {
+func viewMatch(session database.Session, analysis *database.Analysis) *tview.Flex {
 	moveIdx = 0
-	prevGame := game.InitGame([2]string{"-1", "-2"})
+	status := session.Status
+	// replayed from the starting position of the match, in its variant
+	prevGame, err := game.RestoreGame([2]string{"-1", "-2"}, session.Variant, session.StartFEN, nil, "")
+	if err != nil {
+		showViewMatchErrorDialog("Couldn't restore match")
+		os.Exit(1)
+	}
 	boardStates = [][8][8]string{prevGame.GetBoard()}
-	for i, move := range moves {
-		pos := strings.Split(move, "-")
-		if i%2 == 0 {
-			if err := prevGame.MakeMove("-1", pos[0], pos[1]); err != nil {
-				showViewMatchErrorDialog("Coulnd't parse move")
-				os.Exit(1)
-			}
-		} else {
-			if err := prevGame.MakeMove("-2", pos[0], pos[1]); err != nil {
-				showViewMatchErrorDialog("Coulnd't parse move")
-				os.Exit(1)
-			}
+	for _, move := range session.Moves {
+		pos, err := game.ParseMove(move)
+		if err != nil {
+			showViewMatchErrorDialog("Coulnd't parse move")
+			os.Exit(1)
+		}
+		playerID := "-2"
+		if prevGame.GetCurrentTurn() {
+			playerID = "-1"
+		}
+		if err := prevGame.MakeMove(playerID, pos[0], pos[1]); err != nil {
+			showViewMatchErrorDialog("Coulnd't parse move")
+			os.Exit(1)
 		}
 		boardStates = append(boardStates, prevGame.GetBoard())
 	}
-	if !prevGame.IsOver() {
+	// games ended by a claim or a player don't end on the board
+	if !prevGame.IsOver() && status == "ACTIVE" {
 		showViewMatchErrorDialog("Invalid game")
 		os.Exit(1)
 	}
@@ -141,7 +149,7 @@ func viewMatch(moves []string) *tview.Flex {
 
 	updateBoardView := func() {
 		board := boardStates[moveIdx]
-		boardView.SetText(formatBoard(board))
+		boardView.SetText(formatBoard(board) + "\nVariant: " + prevGame.Variant().Title() + "\nResult: " + status + formatAnalysis(analysis, moveIdx))
 	}
 
 	updateBoardView()
@@ -154,7 +162,7 @@ func viewMatch(moves []string) *tview.Flex {
 			}
 		}).
 		AddButton("Next", func() {
-			if moveIdx < len(moves)-1 {
+			if moveIdx < len(boardStates)-1 {
 				moveIdx += 1
 				updateBoardView()
 			}
@@ -389,6 +397,52 @@ func login(user User) {
 	showLoginSuccessDialog("Login successful!")
 }
 
+// analysis of the move leading to the board shown and the accuracy of the players
+func formatAnalysis(analysis *database.Analysis, moveIdx int) string {
+	if analysis == nil {
+		return ""
+	}
+	text := fmt.Sprintf("\nAccuracy: white %.1f, black %.1f", analysis.WhiteAccuracy, analysis.BlackAccuracy)
+	if moveIdx == 0 || moveIdx > len(analysis.Moves) {
+		return text
+	}
+	move := analysis.Moves[moveIdx-1]
+	color := map[string]string{
+		"best":       "green",
+		"good":       "white",
+		"inaccuracy": "yellow",
+		"mistake":    "orange",
+		"blunder":    "red",
+	}[move.Classification]
+	eval := fmt.Sprintf("%+.2f", float64(move.Score)/100)
+	if move.Mate != 0 {
+		eval = fmt.Sprintf("#%d", move.Mate)
+	}
+	text += fmt.Sprintf("\nMove %d. %s: [%s]%s[white] (%s)", moveIdx, move.Move, color, move.Classification, eval)
+	if move.Classification != "best" {
+		text += ", best was " + move.BestMove
+	}
+	return text
+}
+
+// analysis of a finished match, nil until it's done
+func getAnalysis(sessionID string) *database.Analysis {
+	resp, err := http.Get("http://localhost:7202/api/sessions/" + sessionID + "/analysis")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	var analysis database.Analysis
+	if err := json.NewDecoder(resp.Body).Decode(&analysis); err != nil || analysis.Status != database.AnalysisDone {
+		return nil
+	}
+	return &analysis
+}
+
 func getPreviousSessions() {
 	if playerID == "" {
 		return